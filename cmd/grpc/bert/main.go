@@ -0,0 +1,23 @@
+// Command bert is the out-of-process worker binary for bert-family embedding
+// models. It follows the same worker protocol as cmd/grpc/llama.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ollama/ollama/pkg/backend/worker"
+)
+
+func main() {
+	sock := flag.String("socket", "", "unix socket path to listen on")
+	flag.Parse()
+
+	if *sock == "" {
+		log.Fatal("bert: -socket is required")
+	}
+
+	if err := worker.Serve(*sock, &worker.NotImplemented{Name: "bert"}); err != nil {
+		log.Fatalf("bert: %v", err)
+	}
+}
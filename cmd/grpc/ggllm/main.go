@@ -0,0 +1,23 @@
+// Command ggllm is the out-of-process worker binary for falcon/ggllm-family
+// models. It follows the same worker protocol as cmd/grpc/llama.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ollama/ollama/pkg/backend/worker"
+)
+
+func main() {
+	sock := flag.String("socket", "", "unix socket path to listen on")
+	flag.Parse()
+
+	if *sock == "" {
+		log.Fatal("ggllm: -socket is required")
+	}
+
+	if err := worker.Serve(*sock, &worker.NotImplemented{Name: "ggllm"}); err != nil {
+		log.Fatalf("ggllm: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+// Command gpt4all is the out-of-process worker binary for gpt4all-family
+// models. It follows the same worker protocol as cmd/grpc/llama.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ollama/ollama/pkg/backend/worker"
+)
+
+func main() {
+	sock := flag.String("socket", "", "unix socket path to listen on")
+	flag.Parse()
+
+	if *sock == "" {
+		log.Fatal("gpt4all: -socket is required")
+	}
+
+	if err := worker.Serve(*sock, &worker.NotImplemented{Name: "gpt4all"}); err != nil {
+		log.Fatalf("gpt4all: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+// Command llama is the out-of-process worker binary for the llama backend.
+// The ollama server spawns one of these per loaded llama-family model and
+// talks to it over the worker protocol defined in pkg/backend/worker.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/jmorganca/ollama/llm"
+	"github.com/ollama/ollama/pkg/backend/worker"
+)
+
+func main() {
+	sock := flag.String("socket", "", "unix socket path to listen on")
+	flag.Parse()
+
+	if *sock == "" {
+		log.Fatal("llama: -socket is required")
+	}
+
+	if err := worker.Serve(*sock, llm.NewBackend()); err != nil {
+		log.Fatalf("llama: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+// Command piper is the out-of-process worker binary for the piper
+// text-to-speech backend, reached from /api/tts and its OpenAI alias
+// /v1/audio/speech.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ollama/ollama/pkg/backend/worker"
+)
+
+func main() {
+	sock := flag.String("socket", "", "unix socket path to listen on")
+	flag.Parse()
+
+	if *sock == "" {
+		log.Fatal("piper: -socket is required")
+	}
+
+	if err := worker.Serve(*sock, worker.NewPiperCLI()); err != nil {
+		log.Fatalf("piper: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+// Command starcoder is the out-of-process worker binary for starcoder-family
+// code models. It follows the same worker protocol as cmd/grpc/llama.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ollama/ollama/pkg/backend/worker"
+)
+
+func main() {
+	sock := flag.String("socket", "", "unix socket path to listen on")
+	flag.Parse()
+
+	if *sock == "" {
+		log.Fatal("starcoder: -socket is required")
+	}
+
+	if err := worker.Serve(*sock, &worker.NotImplemented{Name: "starcoder"}); err != nil {
+		log.Fatalf("starcoder: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+// Command whisper is the out-of-process worker binary for the whisper.cpp
+// speech-to-text backend, reached from /api/transcribe and its OpenAI alias
+// /v1/audio/transcriptions.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ollama/ollama/pkg/backend/worker"
+)
+
+func main() {
+	sock := flag.String("socket", "", "unix socket path to listen on")
+	flag.Parse()
+
+	if *sock == "" {
+		log.Fatal("whisper: -socket is required")
+	}
+
+	if err := worker.Serve(*sock, worker.NewWhisperCLI()); err != nil {
+		log.Fatalf("whisper: %v", err)
+	}
+}
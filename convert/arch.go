@@ -0,0 +1,274 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/d4l3k/go-bfloat16"
+	"github.com/pdevine/tensor"
+	"github.com/pdevine/tensor/native"
+
+	"github.com/ollama/ollama/llm"
+)
+
+// Rename is one entry of an Architecture's HuggingFace-to-gguf tensor name
+// mapping. Pattern is matched as a regexp against the HuggingFace tensor
+// name; Replacement is applied with regexp.ReplaceAllString, so "$1" etc.
+// refer back to Pattern's capture groups.
+type Rename struct {
+	Pattern     string
+	Replacement string
+}
+
+// Architecture is everything convert needs to turn one HuggingFace model
+// family into a GGUF file: how to recognize it, how its tensors map to
+// gguf names, any per-tensor fix-up the conversion requires, and which KV
+// pairs describe its hyperparameters and tokenizer. Adding a new model
+// family is a matter of implementing this interface and registering it
+// with Register from an init() func, rather than editing the switches
+// that used to live in this file.
+type Architecture interface {
+	// Name is the gguf "general.architecture" value, e.g. "llama".
+	Name() string
+
+	// Detect reports whether params describes a model of this
+	// architecture.
+	Detect(params *Params) bool
+
+	// TensorNameMap returns this architecture's HuggingFace-to-gguf
+	// tensor renames, checked before the shared baseTensorNameMap.
+	TensorNameMap() []Rename
+
+	// Transforms reports whether TransformTensor would modify the named
+	// (already gguf-renamed) tensor, so convertTensor can tell up front
+	// whether it needs the whole tensor in memory or can stream it in chunks.
+	Transforms(name string) bool
+
+	// TransformTensor applies this architecture's fix-up to a decoded
+	// tensor's data, e.g. llama's Q/K repack or gemma's norm+1. Only
+	// called when Transforms(name) is true.
+	TransformTensor(name string, data []float32, params *Params) ([]float32, error)
+
+	// WriteKV adds this architecture's hyperparameter KV pairs (context
+	// length, head counts, and so on) to kv.
+	WriteKV(kv llm.KV, params *Params)
+
+	// TokenizerDefaults adds the tokenizer-related KV pairs this
+	// architecture needs beyond the ones common to every model (special
+	// token ids the model config itself doesn't carry).
+	TokenizerDefaults(kv llm.KV, params *Params)
+}
+
+// StreamingArchitecture is implemented by an Architecture whose
+// TransformTensor fix-up can be computed one row at a time instead of
+// needing the whole tensor decoded into a single []float32. convert
+// prefers it over TransformTensor when converting from an mmapped
+// safetensors file, so that q_proj/k_proj repacks on a 70B-class model
+// don't each require a multi-hundred-megabyte allocation.
+type StreamingArchitecture interface {
+	// TransformRaw applies the same fix-up TransformTensor would to the
+	// tensor named name, reading bf16 rows directly out of raw (rows x
+	// cols, row-major) and writing the final gguf-encoded bytes to w.
+	TransformRaw(w io.Writer, name string, raw []byte, rows, cols int, bo ByteOrder, kind uint32, params *Params) error
+}
+
+var architectures []Architecture
+
+// Register adds a to the set of architectures convert can produce GGUF
+// for. Call it from an init() func; registration order only matters if
+// two architectures' Detect both match the same params, which shouldn't
+// happen in practice.
+func Register(a Architecture) {
+	architectures = append(architectures, a)
+}
+
+// lookupArchitecture returns the registered Architecture matching params.
+func lookupArchitecture(params *Params) (Architecture, error) {
+	switch len(params.Architectures) {
+	case 0:
+		return nil, fmt.Errorf("No architecture specified to convert")
+	case 1:
+	default:
+		return nil, fmt.Errorf("Multimodal models are not yet supported")
+	}
+
+	for _, a := range architectures {
+		if a.Detect(params) {
+			return a, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Models based on '%s' are not yet supported", params.Architectures[0])
+}
+
+// baseTensorNameMap is the HuggingFace-to-gguf tensor renaming shared by
+// every architecture registered in this package. An Architecture's own
+// TensorNameMap is checked first and can add to or override it.
+func baseTensorNameMap() []Rename {
+	return []Rename{
+		{"model.embed_tokens.weight", "token_embd.weight"},
+		{`model.layers.(\d+).input_layernorm.weight`, "blk.$1.attn_norm.weight"},
+		{`model.layers.(\d+).mlp.down_proj.weight`, "blk.$1.ffn_down.weight"},
+		{`model.layers.(\d+).mlp.gate_proj.weight`, "blk.$1.ffn_gate.weight"},
+		{`model.layers.(\d+).mlp.up_proj.weight`, "blk.$1.ffn_up.weight"},
+		{`model.layers.(\d+).post_attention_layernorm.weight`, "blk.$1.ffn_norm.weight"},
+		{`model.layers.(\d+).self_attn.k_proj.weight`, "blk.$1.attn_k.weight"},
+		{`model.layers.(\d+).self_attn.o_proj.weight`, "blk.$1.attn_output.weight"},
+		{`model.layers.(\d+).self_attn.q_proj.weight`, "blk.$1.attn_q.weight"},
+		{`model.layers.(\d+).self_attn.v_proj.weight`, "blk.$1.attn_v.weight"},
+		{"lm_head.weight", "output.weight"},
+		{"model.norm.weight", "output_norm.weight"},
+	}
+}
+
+// renameTensor applies renames (checked in order, first match wins) to n,
+// falling back to an exact match against n itself before trying each
+// pattern as a regexp.
+func renameTensor(renames []Rename, n string) (string, bool) {
+	for _, r := range renames {
+		if r.Pattern == n {
+			return r.Replacement, true
+		}
+	}
+
+	for _, r := range renames {
+		re := regexp.MustCompile(r.Pattern)
+		if newName := re.ReplaceAllString(n, r.Replacement); newName != n {
+			return newName, true
+		}
+	}
+
+	return "", false
+}
+
+// repackQK undoes the rotate-half layout HuggingFace stores q_proj/k_proj
+// weights in, interleaving pairs of rows back into the order llama.cpp's
+// rotary embedding expects. heads is the number of attention heads the
+// tensor is split across (head_count for q, head_count_kv for k), and
+// hiddenSize is the tensor's row length (its in_features).
+func repackQK(data []float32, heads, hiddenSize int) ([]float32, error) {
+	rows := len(data) / hiddenSize
+	n := tensor.New(tensor.WithShape(rows, hiddenSize), tensor.WithBacking(data))
+	origShape := n.Shape().Clone()
+
+	// reshape the tensor and swap axes 1 and 2 to unpack the layer for gguf
+	if err := n.Reshape(heads, 2, origShape[0]/heads/2, origShape[1]); err != nil {
+		return nil, err
+	}
+
+	if err := n.T(0, 2, 1, 3); err != nil {
+		return nil, err
+	}
+
+	if err := n.Reshape(origShape...); err != nil {
+		return nil, err
+	}
+
+	if err := n.Transpose(); err != nil {
+		return nil, err
+	}
+	newN, err := native.SelectF32(n, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var repacked []float32
+	for _, v := range newN {
+		repacked = append(repacked, v...)
+	}
+	return repacked, nil
+}
+
+// qkHeadsForTensor reports the head count repackQK/repackQKRaw should use
+// for the attn_q/attn_k tensor name, shared by llama and qwen2 since both
+// use attnQKPattern to recognize the same tensors and the same
+// rotate-half repack.
+func qkHeadsForTensor(name string, params *Params) (heads int, ok bool) {
+	matches := attnQKPattern.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, false
+	}
+
+	heads = params.AttentionHeads
+	if matches[attnQKPattern.SubexpIndex("layer")] == "k" {
+		heads = params.KeyValHeads
+		if heads == 0 {
+			heads = params.AttentionHeads
+		}
+	}
+
+	return heads, true
+}
+
+// repackQKRaw is repackQK's streaming equivalent: it produces the exact
+// same permuted row order, but reads each destination row's bf16 bytes
+// directly out of raw and writes it to w as soon as it's decoded, so the
+// whole tensor is never held as one big []float32 at once — only one
+// row's worth (plus up to one quantBlock's carry) is live at a time.
+func repackQKRaw(w io.Writer, raw []byte, heads, hiddenSize int, bo ByteOrder, kind uint32) error {
+	const bf16Size = 2
+	rowBytes := hiddenSize * bf16Size
+	rows := len(raw) / rowBytes
+	rowsPerHead := rows / heads
+	half := rowsPerHead / 2
+
+	var carry []float32 // holds any block-quantized tail that spans a row boundary
+	for dst := range rows {
+		head := dst / rowsPerHead
+		within := dst % rowsPerHead
+		a := within % 2
+		b := within / 2
+		src := head*rowsPerHead + a*half + b
+
+		rowData := bfloat16.DecodeFloat32(raw[src*rowBytes : (src+1)*rowBytes])
+		finished := dst == rows-1
+
+		if !isQuantizedKind(kind) {
+			if err := writeTensorData(w, bo, rowData, kind); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pending := append(carry, rowData...)
+		whole := len(pending) / quantBlock * quantBlock
+		if finished && whole < len(pending) {
+			// pad the tensor's final partial block with zeros
+			pending = append(pending, make([]float32, quantBlock-(len(pending)-whole))...)
+			whole = len(pending)
+		}
+
+		if err := writeTensorData(w, bo, pending[:whole], kind); err != nil {
+			return err
+		}
+		carry = append([]float32{}, pending[whole:]...)
+	}
+
+	return nil
+}
+
+// addOnes adds 1 to every element of a 1-D tensor, the fix-up gemma's
+// RMSNorm weights need since it stores them relative to 0 rather than 1.
+func addOnes(data []float32) ([]float32, error) {
+	n := tensor.New(tensor.WithShape(len(data)), tensor.WithBacking(data))
+	ones := tensor.Ones(tensor.Float32, len(data))
+
+	var err error
+	n, err = n.Add(ones)
+	if err != nil {
+		return nil, err
+	}
+
+	newN, err := native.SelectF32(n, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var fullTensor []float32
+	for _, v := range newN {
+		fullTensor = append(fullTensor, v...)
+	}
+
+	return fullTensor, nil
+}
@@ -0,0 +1,50 @@
+package convert
+
+import (
+	"strings"
+
+	"github.com/ollama/ollama/llm"
+)
+
+func init() {
+	Register(gemmaArchitecture{})
+}
+
+// gemmaArchitecture covers llama.cpp's "gemma" gguf architecture, i.e.
+// HuggingFace models published as GemmaForCausalLM.
+type gemmaArchitecture struct{}
+
+func (gemmaArchitecture) Name() string { return "gemma" }
+
+func (gemmaArchitecture) Detect(params *Params) bool {
+	return len(params.Architectures) == 1 && params.Architectures[0] == "GemmaForCausalLM"
+}
+
+func (gemmaArchitecture) TensorNameMap() []Rename { return nil }
+
+func (gemmaArchitecture) Transforms(name string) bool {
+	return strings.HasSuffix(name, "norm.weight")
+}
+
+// TransformTensor adds 1 to gemma's RMSNorm weights, which HuggingFace
+// stores relative to 0 rather than 1.
+func (gemmaArchitecture) TransformTensor(name string, data []float32, params *Params) ([]float32, error) {
+	return addOnes(data)
+}
+
+func (gemmaArchitecture) WriteKV(kv llm.KV, params *Params) {
+	kv["gemma.context_length"] = uint32(params.ContextSize)
+	kv["gemma.embedding_length"] = uint32(params.HiddenSize)
+	kv["gemma.block_count"] = uint32(params.HiddenLayers)
+	kv["gemma.feed_forward_length"] = uint32(params.IntermediateSize)
+	kv["gemma.attention.head_count"] = uint32(params.AttentionHeads)
+	kv["gemma.attention.head_count_kv"] = uint32(params.KeyValHeads)
+	kv["gemma.attention.layer_norm_rms_epsilon"] = float32(params.NormEPS)
+	kv["gemma.attention.key_length"] = uint32(params.HeadDimension)
+	kv["gemma.attention.value_length"] = uint32(params.HeadDimension)
+}
+
+func (gemmaArchitecture) TokenizerDefaults(kv llm.KV, params *Params) {
+	kv["tokenizer.ggml.padding_token_id"] = uint32(params.PaddingTokenID)
+	kv["tokenizer.ggml.unknown_token_id"] = uint32(3)
+}
@@ -0,0 +1,80 @@
+package convert
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/ollama/ollama/llm"
+)
+
+func init() {
+	Register(llamaArchitecture{})
+}
+
+// llamaArchitecture covers llama.cpp's "llama" gguf architecture, which in
+// this package's convert flow means HuggingFace models published as
+// MistralForCausalLM.
+type llamaArchitecture struct{}
+
+// attnQKPattern matches both the attn_q/attn_k weight and (qwen2's) bias
+// tensors: llama.cpp applies the same rotate-half permutation to the bias
+// row-vector as to the weight it's added to, so both need repackQK/
+// repackQKRaw with the same head count.
+var attnQKPattern = regexp.MustCompile(`^blk\.[0-9]+\.attn_(?P<layer>q|k)\.(weight|bias)$`)
+
+func (llamaArchitecture) Name() string { return "llama" }
+
+func (llamaArchitecture) Detect(params *Params) bool {
+	return len(params.Architectures) == 1 && params.Architectures[0] == "MistralForCausalLM"
+}
+
+func (llamaArchitecture) TensorNameMap() []Rename { return nil }
+
+func (llamaArchitecture) Transforms(name string) bool {
+	return attnQKPattern.MatchString(name)
+}
+
+func (llamaArchitecture) TransformTensor(name string, data []float32, params *Params) ([]float32, error) {
+	heads, ok := qkHeadsForTensor(name, params)
+	if !ok {
+		return data, nil
+	}
+
+	hiddenSize := params.HiddenSize
+	if strings.HasSuffix(name, ".bias") {
+		// a bias is a single out_features-length row, not a matrix of
+		// in_features-wide rows.
+		hiddenSize = 1
+	}
+
+	return repackQK(data, heads, hiddenSize)
+}
+
+func (llamaArchitecture) TransformRaw(w io.Writer, name string, raw []byte, rows, cols int, bo ByteOrder, kind uint32, params *Params) error {
+	heads, ok := qkHeadsForTensor(name, params)
+	if !ok {
+		return writeChunked(w, bo, raw, kind)
+	}
+
+	return repackQKRaw(w, raw, heads, cols, bo, kind)
+}
+
+func (llamaArchitecture) WriteKV(kv llm.KV, params *Params) {
+	kv["llama.context_length"] = uint32(params.ContextSize)
+	kv["llama.embedding_length"] = uint32(params.HiddenSize)
+	kv["llama.block_count"] = uint32(params.HiddenLayers)
+	kv["llama.feed_forward_length"] = uint32(params.IntermediateSize)
+	kv["llama.rope.dimension_count"] = uint32(params.HiddenSize / params.AttentionHeads)
+	slog.Debug(fmt.Sprintf("rope dim count = %d", kv["llama.rope.dimension_count"]))
+	kv["llama.attention.head_count"] = uint32(params.AttentionHeads)
+	kv["llama.attention.head_count_kv"] = uint32(params.KeyValHeads)
+	kv["llama.attention.layer_norm_rms_epsilon"] = float32(params.NormEPS)
+	kv["llama.rope.freq_base"] = float32(params.RopeFreqBase)
+}
+
+func (llamaArchitecture) TokenizerDefaults(kv llm.KV, params *Params) {
+	kv["tokenizer.ggml.unknown_token_id"] = uint32(0)
+}
@@ -0,0 +1,81 @@
+package convert
+
+import (
+	"io"
+	"strings"
+
+	"github.com/ollama/ollama/llm"
+)
+
+func init() {
+	Register(qwen2Architecture{})
+}
+
+// qwen2Architecture covers llama.cpp's "qwen2" gguf architecture, i.e.
+// HuggingFace models published as Qwen2ForCausalLM. It needs the same
+// attn_q/attn_k repack as llama, but additionally carries bias tensors on
+// those same projections, which llama/mistral don't have; attnQKPattern
+// matches both so the bias gets the identical rotate-half permutation as
+// the weight it's added to.
+type qwen2Architecture struct{}
+
+func (qwen2Architecture) Name() string { return "qwen2" }
+
+func (qwen2Architecture) Detect(params *Params) bool {
+	return len(params.Architectures) == 1 && params.Architectures[0] == "Qwen2ForCausalLM"
+}
+
+func (qwen2Architecture) TensorNameMap() []Rename {
+	return []Rename{
+		{`model.layers.(\d+).self_attn.q_proj.bias`, "blk.$1.attn_q.bias"},
+		{`model.layers.(\d+).self_attn.k_proj.bias`, "blk.$1.attn_k.bias"},
+		{`model.layers.(\d+).self_attn.v_proj.bias`, "blk.$1.attn_v.bias"},
+	}
+}
+
+func (qwen2Architecture) Transforms(name string) bool {
+	return attnQKPattern.MatchString(name)
+}
+
+func (qwen2Architecture) TransformTensor(name string, data []float32, params *Params) ([]float32, error) {
+	heads, ok := qkHeadsForTensor(name, params)
+	if !ok {
+		return data, nil
+	}
+
+	hiddenSize := params.HiddenSize
+	if strings.HasSuffix(name, ".bias") {
+		// attn_q.bias/attn_k.bias carry the same rotate-half permutation
+		// as the weight they're added to, but as a single
+		// out_features-length row rather than a matrix of in_features-wide
+		// rows.
+		hiddenSize = 1
+	}
+
+	return repackQK(data, heads, hiddenSize)
+}
+
+func (qwen2Architecture) TransformRaw(w io.Writer, name string, raw []byte, rows, cols int, bo ByteOrder, kind uint32, params *Params) error {
+	heads, ok := qkHeadsForTensor(name, params)
+	if !ok {
+		return writeChunked(w, bo, raw, kind)
+	}
+
+	return repackQKRaw(w, raw, heads, cols, bo, kind)
+}
+
+func (qwen2Architecture) WriteKV(kv llm.KV, params *Params) {
+	kv["qwen2.context_length"] = uint32(params.ContextSize)
+	kv["qwen2.embedding_length"] = uint32(params.HiddenSize)
+	kv["qwen2.block_count"] = uint32(params.HiddenLayers)
+	kv["qwen2.feed_forward_length"] = uint32(params.IntermediateSize)
+	kv["qwen2.rope.dimension_count"] = uint32(params.HiddenSize / params.AttentionHeads)
+	kv["qwen2.attention.head_count"] = uint32(params.AttentionHeads)
+	kv["qwen2.attention.head_count_kv"] = uint32(params.KeyValHeads)
+	kv["qwen2.attention.layer_norm_rms_epsilon"] = float32(params.NormEPS)
+	kv["qwen2.rope.freq_base"] = float32(params.RopeFreqBase)
+}
+
+func (qwen2Architecture) TokenizerDefaults(kv llm.KV, params *Params) {
+	kv["tokenizer.ggml.padding_token_id"] = uint32(params.PaddingTokenID)
+}
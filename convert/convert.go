@@ -10,14 +10,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
 	"slices"
-	"strings"
 
-	"github.com/d4l3k/go-bfloat16"
-	"github.com/mitchellh/mapstructure"
-	"github.com/pdevine/tensor"
-	"github.com/pdevine/tensor/native"
 	"github.com/x448/float16"
 	"google.golang.org/protobuf/proto"
 
@@ -55,102 +49,126 @@ type MetaData struct {
 	Offsets []int  `mapstructure:"data_offsets"`
 }
 
-func ReadSafeTensors(fn string, offset uint64, params *Params) ([]llm.Tensor, uint64, error) {
-	f, err := os.Open(fn)
-	if err != nil {
-		return nil, 0, err
+// ConvertOptions controls how GetSafeTensors/ReadSafeTensors/WriteGGUF lay
+// tensors out in the resulting GGUF: plain F32/F16, or one of the ggml
+// block quantizations (Q4_0, Q4_1, Q5_0, Q5_1, Q8_0). An empty Quantization
+// keeps the previous behavior of writing 2-D weights as F16; 1-D tensors
+// (norms and biases) are written as F32 whatever Quantization says.
+type ConvertOptions struct {
+	// Quantization is the default ggml quantization tensors are written as.
+	Quantization string
+
+	// MixPolicy overrides Quantization for individual tensors, e.g. to keep
+	// attn_v/ffn_down at a higher precision than the rest of the model. A
+	// nil MixPolicy uses DefaultMixPolicy(Quantization).
+	MixPolicy *MixPolicy
+}
+
+// resolveMixPolicy returns o's effective mix policy, defaulting to
+// DefaultMixPolicy(o.Quantization) when none was set explicitly.
+func (o ConvertOptions) resolveMixPolicy() MixPolicy {
+	if o.MixPolicy != nil {
+		return *o.MixPolicy
 	}
-	defer f.Close()
+	return DefaultMixPolicy(o.Quantization)
+}
 
-	var jsonSize uint64
-	if err := binary.Read(f, binary.LittleEndian, &jsonSize); err != nil {
+// ReadSafeTensors builds the GGUF tensor list for the safetensors file fn.
+// The file is mmapped rather than read into a buffer, and each tensor's
+// header is parsed one at a time rather than decoded into a
+// map[string]interface{}, so the raw shard bytes are never copied into
+// heap memory. Once every header is parsed and sorted, each tensor's
+// conversion is submitted to a bounded worker pool; WriteTo on the
+// returned tensors' WriterTo just waits for that work to finish. Each
+// converted tensor's bytes stay in memory until its WriteTo is called, so
+// by the time the gguf encoder starts reading, the file's whole converted
+// output can be held in heap memory.
+func ReadSafeTensors(fn string, offset uint64, params *Params, opts ConvertOptions) ([]llm.Tensor, uint64, error) {
+	arch, err := lookupArchitecture(params)
+	if err != nil {
 		return nil, 0, err
 	}
 
-	buf := make([]byte, jsonSize)
-	_, err = io.ReadFull(f, buf)
+	m, err := mmapOpen(fn)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	d := json.NewDecoder(bytes.NewBuffer(buf))
-	d.UseNumber()
-	var parsed map[string]interface{}
-	if err = d.Decode(&parsed); err != nil {
-		return nil, 0, err
+	if len(m.Data) < 8 {
+		return nil, 0, fmt.Errorf("convert: %s is too small to be a safetensors file", fn)
 	}
 
-	var keys []string
-	for k := range parsed {
-		keys = append(keys, k)
+	jsonSize := binary.LittleEndian.Uint64(m.Data[:8])
+	headerEnd := 8 + jsonSize
+
+	headers, err := parseSafetensorsHeader(bytes.NewReader(m.Data[8:headerEnd]))
+	if err != nil {
+		return nil, 0, err
 	}
 
-	slices.Sort(keys)
+	slices.SortFunc(headers, func(a, b tensorHeader) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
 
 	slog.Info("converting layers")
 
+	pool := newConversionPool()
+
 	var tensors []llm.Tensor
-	for _, k := range keys {
-		vals := parsed[k].(map[string]interface{})
-		var data MetaData
-		if err = mapstructure.Decode(vals, &data); err != nil {
+	for _, h := range headers {
+		ggufName, err := GetTensorName(arch, h.Name)
+		if err != nil {
+			slog.Error(fmt.Sprintf("%v", err))
 			return nil, 0, err
 		}
 
 		var size uint64
 		var kind uint32
-		switch len(data.Shape) {
-		case 0:
-			// metadata
-			continue
+		switch len(h.Shape) {
 		case 1:
-			// convert to float32
-			kind = 0
-			size = uint64(data.Shape[0] * 4)
+			// norms and biases stay full precision regardless of opts
+			kind = ggmlTypeF32
+			size = uint64(h.Shape[0] * 4)
 		case 2:
-			// convert to float16
-			kind = 1
-			size = uint64(data.Shape[0] * data.Shape[1] * 2)
-		}
-
-		ggufName, err := GetTensorName(k)
-		if err != nil {
-			slog.Error("%v", err)
-			return nil, 0, err
+			kind, err = quantKindForTensor(opts.resolveMixPolicy(), ggufName)
+			if err != nil {
+				return nil, 0, err
+			}
+			size = quantizedTensorSize(kind, uint64(h.Shape[0])*uint64(h.Shape[1]))
 		}
 
-		shape := []uint64{0, 0, 0, 0}
-		for i := range data.Shape {
-			shape[i] = uint64(data.Shape[i])
+		shape := make([]uint64, 4)
+		for i := range h.Shape {
+			shape[i] = uint64(h.Shape[i])
 		}
 
 		t := llm.Tensor{
 			Name:   ggufName,
 			Kind:   kind,
 			Offset: offset,
-			Shape:  shape[:],
+			Shape:  shape,
 		}
 
-		t.WriterTo = safetensorWriterTo{
-			t:           &t,
-			params:      params,
-			bo:          params.ByteOrder,
-			headCount:   uint32(params.AttentionHeads),
-			headCountKV: uint32(params.KeyValHeads),
-			filename:    fn,
-			start:       uint64(data.Offsets[0]),
-			end:         uint64(data.Offsets[1]),
-			padding:     8 + jsonSize,
-		}
+		start, end := headerEnd+uint64(h.Offsets[0]), headerEnd+uint64(h.Offsets[1])
+		raw := m.Data[start:end]
+		name, tShape, tKind := ggufName, shape[:len(h.Shape)], kind
+		result := pool.submit(func() ([]byte, error) {
+			return convertTensor(name, raw, tShape, tKind, params)
+		})
+
+		t.WriterTo = safetensorWriterTo{result: result}
 
 		slog.Debug(fmt.Sprintf("%v", t))
 		tensors = append(tensors, t)
 		offset += size
 	}
+
+	pool.close()
+
 	return tensors, offset, nil
 }
 
-func GetSafeTensors(dirpath string, params *Params) ([]llm.Tensor, error) {
+func GetSafeTensors(dirpath string, params *Params, opts ConvertOptions) ([]llm.Tensor, error) {
 	var tensors []llm.Tensor
 	files, err := filepath.Glob(filepath.Join(dirpath, "/model-*.safetensors"))
 	if err != nil {
@@ -161,7 +179,7 @@ func GetSafeTensors(dirpath string, params *Params) ([]llm.Tensor, error) {
 	for _, f := range files {
 		var t []llm.Tensor
 		var err error
-		t, offset, err = ReadSafeTensors(f, offset, params)
+		t, offset, err = ReadSafeTensors(f, offset, params, opts)
 		if err != nil {
 			slog.Error("%v", err)
 			return nil, err
@@ -196,9 +214,35 @@ type Vocab struct {
 	Tokens []string
 	Scores []float32
 	Types  []int32
+
+	// Model is the gguf tokenizer.ggml.model value: "llama" for the
+	// sentencepiece vocab read from tokenizer.model, "gpt2" for the BPE
+	// vocab read from tokenizer.json.
+	Model string
+
+	// Merges holds the BPE merge rules in priority order, in gguf's
+	// "left right" format. Only set when Model == "gpt2".
+	Merges []string
+
+	// Pre is the llama.cpp pre-tokenizer regex family this vocab was
+	// detected as using, e.g. "llama-bpe". Only set when Model == "gpt2".
+	Pre string
 }
 
+// LoadTokens loads a model's vocabulary, preferring the sentencepiece
+// tokenizer.model when present and falling back to the HuggingFace fast
+// tokenizer's tokenizer.json otherwise.
 func LoadTokens(dirpath string, params *Params) (*Vocab, error) {
+	if _, err := os.Stat(filepath.Join(dirpath, "tokenizer.model")); err == nil {
+		return loadSentencePieceVocab(dirpath, params)
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return loadBPEVocab(dirpath, params)
+}
+
+func loadSentencePieceVocab(dirpath string, params *Params) (*Vocab, error) {
 	slog.Info(fmt.Sprintf("reading vocab from %s", filepath.Join(dirpath, "tokenizer.model")))
 	in, err := os.ReadFile(filepath.Join(dirpath, "tokenizer.model"))
 	if err != nil {
@@ -213,6 +257,7 @@ func LoadTokens(dirpath string, params *Params) (*Vocab, error) {
 	}
 
 	v := &Vocab{
+		Model:  "llama",
 		Tokens: make([]string, 0),
 		Scores: make([]float32, 0),
 		Types:  make([]int32, 0),
@@ -291,302 +336,84 @@ func LoadTokens(dirpath string, params *Params) (*Vocab, error) {
 	return v, nil
 }
 
-func GetTensorName(n string) (string, error) {
-	tMap := map[string]string{
-		"model.embed_tokens.weight":                           "token_embd.weight",
-		"model.layers.(\\d+).input_layernorm.weight":          "blk.$1.attn_norm.weight",
-		"model.layers.(\\d+).mlp.down_proj.weight":            "blk.$1.ffn_down.weight",
-		"model.layers.(\\d+).mlp.gate_proj.weight":            "blk.$1.ffn_gate.weight",
-		"model.layers.(\\d+).mlp.up_proj.weight":              "blk.$1.ffn_up.weight",
-		"model.layers.(\\d+).post_attention_layernorm.weight": "blk.$1.ffn_norm.weight",
-		"model.layers.(\\d+).self_attn.k_proj.weight":         "blk.$1.attn_k.weight",
-		"model.layers.(\\d+).self_attn.o_proj.weight":         "blk.$1.attn_output.weight",
-		"model.layers.(\\d+).self_attn.q_proj.weight":         "blk.$1.attn_q.weight",
-		"model.layers.(\\d+).self_attn.v_proj.weight":         "blk.$1.attn_v.weight",
-		"lm_head.weight":    "output.weight",
-		"model.norm.weight": "output_norm.weight",
-	}
-
-	v, ok := tMap[n]
-	if ok {
+// GetTensorName maps a HuggingFace safetensors tensor name to its gguf
+// equivalent, checking arch's own TensorNameMap before the shared
+// baseTensorNameMap.
+func GetTensorName(arch Architecture, n string) (string, error) {
+	renames := append(arch.TensorNameMap(), baseTensorNameMap()...)
+	if v, ok := renameTensor(renames, n); ok {
 		return v, nil
 	}
 
-	// quick hack to rename the layers to gguf format
-	for k, v := range tMap {
-		re := regexp.MustCompile(k)
-		newName := re.ReplaceAllString(n, v)
-		if newName != n {
-			return newName, nil
-		}
-	}
-
 	return "", fmt.Errorf("couldn't find a layer name for '%s'", n)
 }
 
+// safetensorWriterTo waits on the conversionPool result for one tensor
+// and copies its already gguf-encoded bytes verbatim, so the WriterTo
+// contract the gguf encoder calls sequentially never itself runs a
+// tensor's conversion; it only blocks until the pool has finished it.
 type safetensorWriterTo struct {
-	t *llm.Tensor
-
-	params      *Params
-	bo          ByteOrder
-	headCount   uint32
-	headCountKV uint32
-
-	filename string
-
-	start, end, padding uint64
+	result <-chan tensorResult
 }
 
-func (r safetensorWriterTo) addOnes(data []float32) ([]float32, error) {
-	n := tensor.New(tensor.WithShape(int(r.t.Shape[0])), tensor.WithBacking(data))
-	ones := tensor.Ones(tensor.Float32, int(r.t.Shape[0]))
-
-	var err error
-	n, err = n.Add(ones)
-	if err != nil {
-		return []float32{}, err
-	}
-
-	newN, err := native.SelectF32(n, 0)
-	if err != nil {
-		return []float32{}, err
-	}
-
-	var fullTensor []float32
-	for _, v := range newN {
-		fullTensor = append(fullTensor, v...)
-	}
-
-	return fullTensor, nil
-}
-
-func (r safetensorWriterTo) repack(data []uint16, heads int) ([]uint16, error) {
-	n := tensor.New(tensor.WithShape(int(r.t.Shape[0]), int(r.t.Shape[1])), tensor.WithBacking(data))
-	origShape := n.Shape().Clone()
-
-	// reshape the tensor and swap axes 1 and 2 to unpack the layer for gguf
-	if err := n.Reshape(heads, 2, origShape[0]/heads/2, origShape[1]); err != nil {
-		return nil, err
-	}
-
-	if err := n.T(0, 2, 1, 3); err != nil {
-		return nil, err
-	}
-
-	if err := n.Reshape(origShape...); err != nil {
-		return nil, err
-	}
-
-	if err := n.Transpose(); err != nil {
-		return nil, err
-	}
-	newN, err := native.SelectU16(n, 1)
-	if err != nil {
-		return nil, err
-	}
-
-	var fullTensor []uint16
-	for _, v := range newN {
-		fullTensor = append(fullTensor, v...)
+// writeTensorData writes data (already repacked/fixed-up as needed) to w as
+// kind: F32 or F16 verbatim, or one of the ggml block quantizations in
+// quantBlock-sized groups.
+func writeTensorData(w io.Writer, bo ByteOrder, data []float32, kind uint32) error {
+	switch kind {
+	case ggmlTypeF32:
+		return binary.Write(w, bo, data)
+	case ggmlTypeF16:
+		tempBuf := make([]uint16, len(data))
+		for i, v := range data {
+			tempBuf[i] = uint16(float16.Fromfloat32(v))
+		}
+		return binary.Write(w, bo, tempBuf)
+	default:
+		encoded, err := quantizeRows(data, kind)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(encoded)
+		return err
 	}
-	return fullTensor, nil
 }
 
 func (r safetensorWriterTo) WriteTo(w io.Writer) (n int64, err error) {
-	arch, err := getArchFromParams(r.params)
-	if err != nil {
-		return 0, err
-	}
-
-	f, err := os.Open(r.filename)
-	if err != nil {
-		return 0, err
+	res := <-r.result
+	if res.err != nil {
+		return 0, res.err
 	}
-	defer f.Close()
-
-	if _, err = f.Seek(int64(r.padding+r.start), 0); err != nil {
-		return 0, err
-	}
-
-	switch arch {
-	case "llama":
-
-		pattern := `^blk\.[0-9]+\.attn_(?P<layer>q|k)\.weight$`
-		re, err := regexp.Compile(pattern)
-		if err != nil {
-			return 0, err
-		}
-
-		matches := re.FindAllStringSubmatch(r.t.Name, -1)
-		if len(matches) > 0 {
-			layerSize := r.end - r.start
 
-			var err error
-			tData := make([]uint16, layerSize/2)
-			if err = binary.Read(f, r.bo, tData); err != nil {
-				return 0, err
-			}
-
-			layerType := matches[0][re.SubexpIndex("layer")]
-			var heads uint32
-			switch layerType {
-			case "q":
-				heads = r.headCount
-			case "k":
-				heads = r.headCountKV
-				if heads == 0 {
-					heads = r.headCount
-				}
-			}
-
-			tData, err = r.repack(tData, int(heads))
-			if err != nil {
-				return 0, err
-			}
-
-			var buf []byte
-			for _, n := range tData {
-				buf = r.bo.AppendUint16(buf, n)
-			}
-
-			tempBuf := make([]uint16, len(tData))
-			tDataF32 := bfloat16.DecodeFloat32(buf)
-			for cnt, v := range tDataF32 {
-				tDataF16 := float16.Fromfloat32(v)
-				tempBuf[cnt] = uint16(tDataF16)
-			}
-
-			if err = binary.Write(w, r.bo, tempBuf); err != nil {
-				return 0, err
-			}
-
-			return 0, nil
-		}
-
-	case "gemma":
-		if strings.HasSuffix(r.t.Name, "norm.weight") {
-			slog.Debug(fmt.Sprintf("converting '%s'", r.t.Name))
-
-			data := make([]byte, r.end-r.start)
-			if err = binary.Read(f, r.bo, data); err != nil {
-				return 0, err
-			}
-
-			tDataF32 := bfloat16.DecodeFloat32(data)
-
-			var err error
-			tDataF32, err = r.addOnes(tDataF32)
-			if err != nil {
-				return 0, err
-			}
-
-			if err := binary.Write(w, r.bo, tDataF32); err != nil {
-				return 0, err
-			}
-			return 0, nil
-		}
-	}
-
-	remaining := r.end - r.start
-
-	bufSize := uint64(10240)
-	var finished bool
-	for {
-		data := make([]byte, min(bufSize, remaining))
-
-		b, err := io.ReadFull(f, data)
-		remaining -= uint64(b)
-
-		if err == io.EOF || remaining <= 0 {
-			finished = true
-		} else if err != nil {
-			return 0, err
-		}
-
-		// convert bfloat16 -> ieee float32
-		tDataF32 := bfloat16.DecodeFloat32(data)
-
-		switch r.t.Kind {
-		case 0:
-			if err := binary.Write(w, r.bo, tDataF32); err != nil {
-				return 0, err
-			}
-		case 1:
-			// convert float32 -> float16
-			tempBuf := make([]uint16, len(data)/2)
-			for cnt, v := range tDataF32 {
-				tDataF16 := float16.Fromfloat32(v)
-				tempBuf[cnt] = uint16(tDataF16)
-			}
-			if err := binary.Write(w, binary.LittleEndian, tempBuf); err != nil {
-				return 0, err
-			}
-		}
-		if finished {
-			break
-		}
-	}
-	return 0, nil
+	written, err := w.Write(res.data)
+	return int64(written), err
 }
 
-func getArchFromParams(params *Params) (string, error) {
-	var arch string
-	switch len(params.Architectures) {
-	case 0:
-		return "", fmt.Errorf("No architecture specified to convert")
-	case 1:
-		switch params.Architectures[0] {
-		case "MistralForCausalLM":
-			arch = "llama"
-		case "GemmaForCausalLM":
-			arch = "gemma"
-		default:
-			return "", fmt.Errorf("Models based on '%s' are not yet supported", params.Architectures[0])
-		}
-	default:
-		return "", fmt.Errorf("Multimodal models are not yet supported")
+func WriteGGUF(name string, tensors []llm.Tensor, params *Params, vocab *Vocab, opts ConvertOptions) (string, error) {
+	arch, err := lookupArchitecture(params)
+	if err != nil {
+		return "", err
 	}
 
-	return arch, nil
-}
-
-func WriteGGUF(name string, tensors []llm.Tensor, params *Params, vocab *Vocab) (string, error) {
-	arch, err := getArchFromParams(params)
+	fileType, err := quantTypeFromName(opts.Quantization)
 	if err != nil {
 		return "", err
 	}
 
 	kv := llm.KV{
-		"general.architecture": arch,
+		"general.architecture": arch.Name(),
 		"general.name":         name,
 	}
 
-	switch arch {
-	case "llama":
-		kv["llama.context_length"] = uint32(params.ContextSize)
-		kv["llama.embedding_length"] = uint32(params.HiddenSize)
-		kv["llama.block_count"] = uint32(params.HiddenLayers)
-		kv["llama.feed_forward_length"] = uint32(params.IntermediateSize)
-		kv["llama.rope.dimension_count"] = uint32(params.HiddenSize / params.AttentionHeads)
-		slog.Debug(fmt.Sprintf("rope dim count = %d", kv["llama.rope.dimension_count"]))
-		kv["llama.attention.head_count"] = uint32(params.AttentionHeads)
-		kv["llama.attention.head_count_kv"] = uint32(params.KeyValHeads)
-		kv["llama.attention.layer_norm_rms_epsilon"] = float32(params.NormEPS)
-		kv["llama.rope.freq_base"] = float32(params.RopeFreqBase)
-	case "gemma":
-		kv["gemma.context_length"] = uint32(params.ContextSize)
-		kv["gemma.embedding_length"] = uint32(params.HiddenSize)
-		kv["gemma.block_count"] = uint32(params.HiddenLayers)
-		kv["gemma.feed_forward_length"] = uint32(params.IntermediateSize)
-		kv["gemma.attention.head_count"] = uint32(params.AttentionHeads)
-		kv["gemma.attention.head_count_kv"] = uint32(params.KeyValHeads)
-		kv["gemma.attention.layer_norm_rms_epsilon"] = float32(params.NormEPS)
-		kv["gemma.attention.key_length"] = uint32(params.HeadDimension)
-		kv["gemma.attention.value_length"] = uint32(params.HeadDimension)
-	}
+	arch.WriteKV(kv, params)
+
+	kv["general.file_type"] = fileType
+	kv["tokenizer.ggml.model"] = vocab.Model
 
-	kv["general.file_type"] = uint32(1)
-	kv["tokenizer.ggml.model"] = "llama"
+	if vocab.Model == "gpt2" {
+		kv["tokenizer.ggml.merges"] = vocab.Merges
+		kv["tokenizer.ggml.pre"] = vocab.Pre
+	}
 
 	kv["tokenizer.ggml.tokens"] = vocab.Tokens
 	kv["tokenizer.ggml.scores"] = vocab.Scores
@@ -595,13 +422,7 @@ func WriteGGUF(name string, tensors []llm.Tensor, params *Params, vocab *Vocab)
 	kv["tokenizer.ggml.bos_token_id"] = uint32(params.BoSTokenID)
 	kv["tokenizer.ggml.eos_token_id"] = uint32(params.EoSTokenID)
 
-	switch arch {
-	case "llama":
-		kv["tokenizer.ggml.unknown_token_id"] = uint32(0)
-	case "gemma":
-		kv["tokenizer.ggml.padding_token_id"] = uint32(params.PaddingTokenID)
-		kv["tokenizer.ggml.unknown_token_id"] = uint32(3)
-	}
+	arch.TokenizerDefaults(kv, params)
 
 	kv["tokenizer.ggml.add_bos_token"] = true
 	kv["tokenizer.ggml.add_eos_token"] = false
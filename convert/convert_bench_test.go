@@ -0,0 +1,142 @@
+package convert
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// readRSSBytes reads this process's resident set size from /proc/self/status,
+// returning ok=false on platforms where that file doesn't exist.
+func readRSSBytes() (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, false
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0, false
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return kb * 1024, true
+	}
+
+	return 0, false
+}
+
+// writeSyntheticSafetensors writes a minimal single-tensor safetensors file
+// (the real on-disk header+data layout ReadSafeTensors parses, not an
+// in-heap stand-in) to name, containing one bf16 tensor of the given shape
+// filled with zero bytes.
+func writeSyntheticSafetensors(name, tensorName string, shape []int) error {
+	n := 1
+	for _, s := range shape {
+		n *= s
+	}
+	data := make([]byte, n*2) // bf16, 2 bytes/element
+
+	header := map[string]any{
+		tensorName: map[string]any{
+			"dtype":        "BF16",
+			"shape":        shape,
+			"data_offsets": []int{0, len(data)},
+		},
+	}
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := binary.Write(f, binary.LittleEndian, uint64(len(headerJSON))); err != nil {
+		return err
+	}
+	if _, err := f.Write(headerJSON); err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// BenchmarkReadSafeTensorsSynthetic70B drives a single synthetic
+// attn_q.weight tensor shaped like llama 3 70B's (hidden_size 8192, square)
+// through the real ReadSafeTensors/mmapOpen path - not convertTensor called
+// directly on an in-heap buffer - to confirm the mmap+streaming path holds
+// RSS growth to a small multiple of that one tensor's decoded size. The
+// file holds only this one tensor, so it does not measure how RSS grows
+// with the number of tensors in a model.
+func BenchmarkReadSafeTensorsSynthetic70B(b *testing.B) {
+	const (
+		hiddenSize     = 8192 // llama 3 70B hidden_size
+		attentionHeads = 64   // llama 3 70B num_attention_heads
+		kvHeads        = 8    // llama 3 70B num_key_value_heads (GQA)
+	)
+
+	largestTensorBytes := uint64(hiddenSize) * uint64(hiddenSize) * 4 // decoded f32
+
+	dir := b.TempDir()
+	fn := dir + "/model-00001-of-00001.safetensors"
+	if err := writeSyntheticSafetensors(fn, "model.layers.0.self_attn.q_proj.weight", []int{hiddenSize, hiddenSize}); err != nil {
+		b.Fatal(err)
+	}
+
+	params := &Params{
+		Architectures:  []string{"MistralForCausalLM"},
+		HiddenSize:     hiddenSize,
+		AttentionHeads: attentionHeads,
+		KeyValHeads:    kvHeads,
+		ByteOrder:      binary.LittleEndian,
+	}
+
+	before, ok := readRSSBytes()
+	if !ok {
+		b.Skip("VmRSS not available on this platform")
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		tensors, _, err := ReadSafeTensors(fn, 0, params, ConvertOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, t := range tensors {
+			if _, err := t.WriterTo.WriteTo(io.Discard); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.StopTimer()
+
+	after, _ := readRSSBytes()
+	if after <= before {
+		return
+	}
+
+	// A generous bound: the mmap itself (one hidden_size^2*2 bf16 mapping,
+	// shared with the page cache) plus a couple of decoded-tensor buffers in
+	// flight, well short of a whole second copy of the model.
+	if grew := after - before; grew > 4*largestTensorBytes {
+		b.Errorf("RSS grew by %d bytes converting one tensor through ReadSafeTensors, more than 4x its decoded size (%d bytes)", grew, largestTensorBytes)
+	}
+}
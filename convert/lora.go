@@ -0,0 +1,259 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/d4l3k/go-bfloat16"
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/ollama/ollama/llm"
+)
+
+// LoRAParams holds the hyperparameters of a PEFT adapter, read from its
+// adapter_config.json: the rank and scaling factor of the low-rank update,
+// and which base-model projections it was trained against.
+type LoRAParams struct {
+	Rank          int      `json:"r"`
+	Alpha         float64  `json:"lora_alpha"`
+	TargetModules []string `json:"target_modules"`
+}
+
+// GetLoRAParams reads a PEFT adapter's adapter_config.json.
+func GetLoRAParams(dirpath string) (*LoRAParams, error) {
+	f, err := os.Open(filepath.Join(dirpath, "adapter_config.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var params LoRAParams
+	if err := json.NewDecoder(f).Decode(&params); err != nil {
+		return nil, err
+	}
+
+	return &params, nil
+}
+
+// loraBaseName strips a PEFT adapter tensor's "base_model.model." prefix
+// and its "lora_A"/"lora_B" suffix, returning the equivalent base-model
+// weight name GetTensorName understands and whether n is the A or B side
+// of the pair. ok is false for any tensor that isn't a LoRA weight, e.g.
+// the safetensors "__metadata__" entry.
+func loraBaseName(n string) (base string, isA bool, ok bool) {
+	n = strings.TrimPrefix(n, "base_model.model.")
+
+	switch {
+	case strings.HasSuffix(n, ".lora_A.weight"):
+		return strings.TrimSuffix(n, ".lora_A.weight") + ".weight", true, true
+	case strings.HasSuffix(n, ".lora_B.weight"):
+		return strings.TrimSuffix(n, ".lora_B.weight") + ".weight", false, true
+	default:
+		return "", false, false
+	}
+}
+
+// loraWriterTo reads a LoRA tensor's raw bytes out of adapter_model.safetensors
+// and writes them as F16, the same bfloat16->f16 path safetensorWriterTo
+// uses for full weights. LoRA factors are small enough to read whole,
+// unlike ReadSafeTensors' chunked fallback for full-size weights.
+type loraWriterTo struct {
+	bo ByteOrder
+
+	filename string
+
+	start, end, padding uint64
+
+	// transform, when set, is applied to the decoded f32 data before it's
+	// written. It's used to repack the loraB (output) factor of an
+	// attn_q/attn_k adapter with the same rotate-half permutation
+	// repackQKRaw applies to the base weight's rows, so the adapter's
+	// deltas land on the same rows llama.cpp's rotary embedding expects
+	// after the base weight has been repacked.
+	transform func([]float32) ([]float32, error)
+}
+
+func (r loraWriterTo) WriteTo(w io.Writer) (int64, error) {
+	f, err := os.Open(r.filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(int64(r.padding+r.start), 0); err != nil {
+		return 0, err
+	}
+
+	data := make([]byte, r.end-r.start)
+	if err := binary.Read(f, r.bo, data); err != nil {
+		return 0, err
+	}
+
+	tDataF32 := bfloat16.DecodeFloat32(data)
+	if r.transform != nil {
+		if tDataF32, err = r.transform(tDataF32); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := writeTensorData(w, r.bo, tDataF32, ggmlTypeF16); err != nil {
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+// ConvertLoRA reads a HuggingFace PEFT/LoRA adapter directory (the rank
+// and target modules from adapter_config.json, the weights from
+// adapter_model.safetensors) and writes it out as a ggml-layout LoRA
+// GGUF: paired blk.N.<proj>.weight.loraA/.loraB tensors alongside the KV
+// entries llama.cpp's LoRA loader expects. params describes the base
+// model the adapter was trained against, the same as GetSafeTensors
+// takes.
+func ConvertLoRA(dirpath string, params *Params) (string, error) {
+	arch, err := lookupArchitecture(params)
+	if err != nil {
+		return "", err
+	}
+
+	lora, err := GetLoRAParams(dirpath)
+	if err != nil {
+		return "", err
+	}
+
+	fn := filepath.Join(dirpath, "adapter_model.safetensors")
+	f, err := os.Open(fn)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var jsonSize uint64
+	if err := binary.Read(f, binary.LittleEndian, &jsonSize); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, jsonSize)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return "", err
+	}
+
+	d := json.NewDecoder(bytes.NewBuffer(buf))
+	d.UseNumber()
+	var parsed map[string]interface{}
+	if err := d.Decode(&parsed); err != nil {
+		return "", err
+	}
+
+	var keys []string
+	for k := range parsed {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	slog.Info(fmt.Sprintf("converting LoRA adapter for %d target modules", len(lora.TargetModules)))
+
+	var tensors []llm.Tensor
+	var offset uint64
+	for _, k := range keys {
+		vals, ok := parsed[k].(map[string]interface{})
+		if !ok {
+			// "__metadata__" and similar
+			continue
+		}
+
+		var data MetaData
+		if err := mapstructure.Decode(vals, &data); err != nil {
+			return "", err
+		}
+
+		if len(data.Shape) == 0 {
+			continue
+		}
+
+		base, isA, ok := loraBaseName(k)
+		if !ok {
+			continue
+		}
+
+		ggufName, err := GetTensorName(arch, base)
+		if err != nil {
+			slog.Error("%v", err)
+			return "", err
+		}
+
+		// loraB is the adapter's output factor: its rows are the same
+		// out_features rows the base q/k weight's rotate-half repack
+		// permutes, so it needs the identical permutation or its deltas
+		// land on the wrong rows once the base weight is repacked.
+		var transform func([]float32) ([]float32, error)
+		if !isA {
+			if heads, ok := qkHeadsForTensor(ggufName, params); ok {
+				rank := int(data.Shape[len(data.Shape)-1])
+				transform = func(d []float32) ([]float32, error) {
+					return repackQK(d, heads, rank)
+				}
+			}
+		}
+
+		if isA {
+			ggufName += ".loraA"
+		} else {
+			ggufName += ".loraB"
+		}
+
+		shape := []uint64{0, 0, 0, 0}
+		elems := uint64(1)
+		for i := range data.Shape {
+			shape[i] = uint64(data.Shape[i])
+			elems *= uint64(data.Shape[i])
+		}
+
+		t := llm.Tensor{
+			Name:   ggufName,
+			Kind:   ggmlTypeF16,
+			Offset: offset,
+			Shape:  shape[:],
+		}
+
+		t.WriterTo = loraWriterTo{
+			bo:        params.ByteOrder,
+			filename:  fn,
+			start:     uint64(data.Offsets[0]),
+			end:       uint64(data.Offsets[1]),
+			padding:   8 + jsonSize,
+			transform: transform,
+		}
+
+		tensors = append(tensors, t)
+		offset += quantizedTensorSize(ggmlTypeF16, elems)
+	}
+
+	kv := llm.KV{
+		"general.architecture": arch.Name(),
+		"general.type":         "adapter",
+		"adapter.type":         "lora",
+		"adapter.lora.alpha":   float32(lora.Alpha),
+	}
+
+	f2, err := os.CreateTemp("", "ollama-gguf-lora")
+	if err != nil {
+		return "", err
+	}
+	defer f2.Close()
+
+	m := llm.NewGGUFV3(params.ByteOrder)
+	if err := m.Encode(f2, kv, tensors); err != nil {
+		return "", err
+	}
+
+	return f2.Name(), nil
+}
@@ -0,0 +1,60 @@
+//go:build unix
+
+package convert
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapFile is a read-only memory map of an entire file. Tensor conversion
+// slices directly into Data instead of seeking and copying, so a single
+// tensor never costs more resident memory than its own decoded size.
+type mmapFile struct {
+	Data []byte
+
+	f *os.File
+}
+
+// mmapOpen opens name and maps it read-only. The mapping is never
+// explicitly unmapped (see ReadSafeTensors): convert is a one-shot
+// process, and a read-only mapping is backed by the page cache rather
+// than anonymous memory, so holding it open for the rest of the run
+// costs no more real memory than the OS was already caching for the
+// file.
+func mmapOpen(name string) (*mmapFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if info.Size() == 0 {
+		return &mmapFile{f: f}, nil
+	}
+
+	data, err := unix.Mmap(int(f.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &mmapFile{Data: data, f: f}, nil
+}
+
+func (m *mmapFile) Close() error {
+	var err error
+	if m.Data != nil {
+		err = unix.Munmap(m.Data)
+	}
+	if cerr := m.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+//go:build !unix
+
+package convert
+
+import "os"
+
+// mmapFile is a read-only memory map of an entire file. Tensor conversion
+// slices directly into Data instead of seeking and copying, so a single
+// tensor never costs more resident memory than its own decoded size.
+type mmapFile struct {
+	Data []byte
+
+	f *os.File
+}
+
+// mmapOpen opens name and reads it into memory. Platforms outside the
+// unix build tag (windows, plan9, js/wasm, ...) have no single
+// mmap.go-compatible syscall package here, so this falls back to a plain
+// read; it costs one copy's worth of resident memory rather than relying
+// on the page cache the way mmap.go's unix implementation does.
+func mmapOpen(name string) (*mmapFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &mmapFile{Data: data, f: f}, nil
+}
+
+func (m *mmapFile) Close() error {
+	return m.f.Close()
+}
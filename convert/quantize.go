@@ -0,0 +1,370 @@
+package convert
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/x448/float16"
+)
+
+// ggml's numeric type enum (see ggml.h GGML_TYPE_*). Only the types this
+// package can produce are listed here.
+const (
+	ggmlTypeF32  = uint32(0)
+	ggmlTypeF16  = uint32(1)
+	ggmlTypeQ4_0 = uint32(2)
+	ggmlTypeQ4_1 = uint32(3)
+	ggmlTypeQ5_0 = uint32(6)
+	ggmlTypeQ5_1 = uint32(7)
+	ggmlTypeQ8_0 = uint32(8)
+)
+
+// quantBlock is 32 elements, the row grouping every ggml block quantization
+// in this package operates on.
+const quantBlock = 32
+
+// quantTypeFromName maps a human-readable quantization name (as used in
+// ConvertOptions.Quantization) to ggml's numeric type. An empty name means F16, the
+// previous hard-coded default.
+func quantTypeFromName(name string) (uint32, error) {
+	switch name {
+	case "", "F16":
+		return ggmlTypeF16, nil
+	case "F32":
+		return ggmlTypeF32, nil
+	case "Q4_0":
+		return ggmlTypeQ4_0, nil
+	case "Q4_1":
+		return ggmlTypeQ4_1, nil
+	case "Q5_0":
+		return ggmlTypeQ5_0, nil
+	case "Q5_1":
+		return ggmlTypeQ5_1, nil
+	case "Q8_0":
+		return ggmlTypeQ8_0, nil
+	default:
+		return 0, fmt.Errorf("convert: unsupported quantization %q", name)
+	}
+}
+
+// quantizedTensorSize returns the on-disk byte size of a tensor of n
+// elements written as kind. Quantized kinds round up to a whole number of
+// quantBlock-element blocks, matching writeChunked/repackQKRaw, which
+// zero-pad a final partial block rather than dropping it.
+func quantizedTensorSize(kind uint32, n uint64) uint64 {
+	blocks := (n + quantBlock - 1) / quantBlock
+	switch kind {
+	case ggmlTypeF32:
+		return n * 4
+	case ggmlTypeQ8_0:
+		return blocks * 34
+	case ggmlTypeQ4_0:
+		return blocks * 18
+	case ggmlTypeQ5_0:
+		return blocks * 22
+	case ggmlTypeQ4_1:
+		return blocks * 20
+	case ggmlTypeQ5_1:
+		return blocks * 24
+	default:
+		// F16, or anything unrecognized: 2 bytes/element.
+		return n * 2
+	}
+}
+
+// isQuantizedKind reports whether kind is one of the block-quantized ggml
+// types this package can emit (as opposed to plain F32/F16).
+func isQuantizedKind(kind uint32) bool {
+	switch kind {
+	case ggmlTypeQ4_0, ggmlTypeQ4_1, ggmlTypeQ5_0, ggmlTypeQ5_1, ggmlTypeQ8_0:
+		return true
+	default:
+		return false
+	}
+}
+
+// MixPolicy lets individual tensors use a different quantization than the
+// model's default, mirroring the "mostly Q4_0 with Q5_1 attn.v/ffn_down"
+// mixing scheme llama.cpp's K-quants popularized. Overrides are matched
+// against the end of the gguf tensor name (e.g. "attn_v.weight").
+type MixPolicy struct {
+	Default   string
+	Overrides map[string]string
+}
+
+// DefaultMixPolicy returns the mix policy llama.cpp uses for its "mostly"
+// quantization schemes: the bulk of the model at base, with the attention
+// value projection and the down-projection of the FFN bumped one notch up
+// for quality, since those tensors are the most sensitive to quantization
+// error.
+func DefaultMixPolicy(base string) MixPolicy {
+	bump := map[string]string{
+		"Q4_0": "Q5_0",
+		"Q4_1": "Q5_1",
+	}[base]
+	if bump == "" {
+		bump = base
+	}
+
+	return MixPolicy{
+		Default: base,
+		Overrides: map[string]string{
+			"attn_v.weight":   bump,
+			"ffn_down.weight": bump,
+		},
+	}
+}
+
+// quantKindForTensor resolves the ggml type a given gguf tensor name should
+// be written as under policy.
+func quantKindForTensor(policy MixPolicy, ggufName string) (uint32, error) {
+	for suffix, q := range policy.Overrides {
+		if hasTensorSuffix(ggufName, suffix) {
+			return quantTypeFromName(q)
+		}
+	}
+	return quantTypeFromName(policy.Default)
+}
+
+func hasTensorSuffix(name, suffix string) bool {
+	if len(name) < len(suffix) {
+		return false
+	}
+	return name[len(name)-len(suffix):] == suffix
+}
+
+// quantizeRows quantizes data - whose length must be a multiple of
+// quantBlock - into ggml's block format for kind, one quantBlock-element
+// block after another, and returns the encoded bytes.
+func quantizeRows(data []float32, kind uint32) ([]byte, error) {
+	if len(data)%quantBlock != 0 {
+		return nil, fmt.Errorf("convert: quantize: length %d is not a multiple of the %d-element block size", len(data), quantBlock)
+	}
+
+	var out []byte
+	for i := 0; i < len(data); i += quantBlock {
+		block := data[i : i+quantBlock]
+
+		var encoded []byte
+		var err error
+		switch kind {
+		case ggmlTypeQ8_0:
+			encoded = quantizeQ8_0Block(block)
+		case ggmlTypeQ4_0:
+			encoded = quantizeQ4_0Block(block)
+		case ggmlTypeQ5_0:
+			encoded = quantizeQ5_0Block(block)
+		case ggmlTypeQ4_1:
+			encoded = quantizeQ4_1Block(block)
+		case ggmlTypeQ5_1:
+			encoded = quantizeQ5_1Block(block)
+		default:
+			err = fmt.Errorf("convert: quantize: unsupported ggml type %d", kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, encoded...)
+	}
+
+	return out, nil
+}
+
+func appendF16(buf []byte, v float32) []byte {
+	var tmp [2]byte
+	binary.LittleEndian.PutUint16(tmp[:], uint16(float16.Fromfloat32(v)))
+	return append(buf, tmp[:]...)
+}
+
+func absMax(block []float32) float32 {
+	var max float32
+	for _, v := range block {
+		if a := float32(math.Abs(float64(v))); a > max {
+			max = a
+		}
+	}
+	return max
+}
+
+// signedAbsMax returns the block's largest-magnitude element, with its
+// original sign, matching ggml's reference quantizers: they track the
+// extremal value itself (not its absolute value) so the scale's sign
+// follows whichever end of the range is furthest from zero.
+func signedAbsMax(block []float32) float32 {
+	var amax, signed float32
+	for _, v := range block {
+		if a := float32(math.Abs(float64(v))); a > amax {
+			amax = a
+			signed = v
+		}
+	}
+	return signed
+}
+
+// quantizeQ8_0Block: one fp16 scale d = max(|x|)/127, then 32 int8 values
+// round(x/d).
+func quantizeQ8_0Block(block []float32) []byte {
+	d := absMax(block) / 127
+	buf := appendF16(nil, d)
+
+	for _, x := range block {
+		q := int8(0)
+		if d != 0 {
+			q = int8(math.Round(float64(x / d)))
+		}
+		buf = append(buf, byte(q))
+	}
+
+	return buf
+}
+
+// quantizeQ4_0Block: one fp16 scale d = max/-8, where max is the
+// largest-magnitude element's signed value, then 16 bytes packing two
+// clamp(round(x/d)+8, 0, 15) nibbles each.
+func quantizeQ4_0Block(block []float32) []byte {
+	d := signedAbsMax(block) / -8
+	buf := appendF16(nil, d)
+
+	nibbles := make([]byte, quantBlock)
+	for i, x := range block {
+		var q int
+		if d != 0 {
+			q = int(math.Round(float64(x / d)))
+		}
+		q += 8
+		nibbles[i] = byte(clampInt(q, 0, 15))
+	}
+
+	buf = append(buf, packNibbles(nibbles)...)
+	return buf
+}
+
+// quantizeQ5_0Block is Q4_0's 5-bit sibling: the same fp16 scale (divisor
+// -16 to cover the wider range) and nibble packing, plus a 4-byte mask
+// carrying the 5th (high) bit of every value in the block.
+func quantizeQ5_0Block(block []float32) []byte {
+	d := signedAbsMax(block) / -16
+	buf := appendF16(nil, d)
+
+	codes := make([]int, quantBlock)
+	for i, x := range block {
+		var q int
+		if d != 0 {
+			q = int(math.Round(float64(x / d)))
+		}
+		codes[i] = clampInt(q+16, 0, 31)
+	}
+
+	buf = append(buf, highBitMask(codes)...)
+
+	nibbles := make([]byte, quantBlock)
+	for i, c := range codes {
+		nibbles[i] = byte(c & 0xF)
+	}
+	buf = append(buf, packNibbles(nibbles)...)
+
+	return buf
+}
+
+// quantizeQ4_1Block: fp16 d and fp16 min m, then 16 bytes of
+// round((x-m)/d) packed into nibbles.
+func quantizeQ4_1Block(block []float32) []byte {
+	min, max := minMax(block)
+	d := (max - min) / 15
+
+	buf := appendF16(nil, d)
+	buf = appendF16(buf, min)
+
+	nibbles := make([]byte, quantBlock)
+	for i, x := range block {
+		var q int
+		if d != 0 {
+			q = int(math.Round(float64((x - min) / d)))
+		}
+		nibbles[i] = byte(clampInt(q, 0, 15))
+	}
+
+	buf = append(buf, packNibbles(nibbles)...)
+	return buf
+}
+
+// quantizeQ5_1Block is Q4_1 widened to 5 bits, with the 5th bit of every
+// code packed into a 4-byte mask the same way Q5_0 does.
+func quantizeQ5_1Block(block []float32) []byte {
+	min, max := minMax(block)
+	d := (max - min) / 31
+
+	buf := appendF16(nil, d)
+	buf = appendF16(buf, min)
+
+	codes := make([]int, quantBlock)
+	for i, x := range block {
+		var q int
+		if d != 0 {
+			q = int(math.Round(float64((x - min) / d)))
+		}
+		codes[i] = clampInt(q, 0, 31)
+	}
+
+	buf = append(buf, highBitMask(codes)...)
+
+	nibbles := make([]byte, quantBlock)
+	for i, c := range codes {
+		nibbles[i] = byte(c & 0xF)
+	}
+	buf = append(buf, packNibbles(nibbles)...)
+
+	return buf
+}
+
+func minMax(block []float32) (min, max float32) {
+	min, max = block[0], block[0]
+	for _, v := range block[1:] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// packNibbles packs 32 4-bit codes into 16 bytes the way ggml's Q4 block
+// layout expects: element j goes in byte j's low nibble and element j+16 in
+// byte j's high nibble (not consecutive pairs 2i/2i+1).
+func packNibbles(codes []byte) []byte {
+	half := len(codes) / 2
+	out := make([]byte, half)
+	for j := 0; j < half; j++ {
+		out[j] = (codes[j] & 0xF) | ((codes[j+half] & 0xF) << 4)
+	}
+	return out
+}
+
+// highBitMask packs the 5th (bit 4) bit of each 5-bit code into a 4-byte
+// little-endian mask, matching ggml's block_q5_0/block_q5_1 qh field.
+func highBitMask(codes []int) []byte {
+	var mask uint32
+	for i, c := range codes {
+		if c&0x10 != 0 {
+			mask |= 1 << uint(i)
+		}
+	}
+
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], mask)
+	return buf[:]
+}
@@ -0,0 +1,148 @@
+package convert
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// dequantize{Q4_0,Q5_0,Q4_1,Q5_1,Q8_0}Block are the inverse of the
+// quantize*Block encoders, reading ggml's actual block layout back out so a
+// regression in the encoder's byte layout (as opposed to just its math)
+// shows up as a round-trip mismatch.
+
+func dequantizeQ4_0Block(buf []byte) []float32 {
+	d := float16.Frombits(binary.LittleEndian.Uint16(buf[0:2])).Float32()
+	qs := buf[2 : 2+quantBlock/2]
+
+	out := make([]float32, quantBlock)
+	half := quantBlock / 2
+	for j := 0; j < half; j++ {
+		out[j] = d * float32(int(qs[j]&0xF)-8)
+		out[j+half] = d * float32(int(qs[j]>>4)-8)
+	}
+	return out
+}
+
+func dequantizeQ5_0Block(buf []byte) []float32 {
+	d := float16.Frombits(binary.LittleEndian.Uint16(buf[0:2])).Float32()
+	qh := binary.LittleEndian.Uint32(buf[2:6])
+	qs := buf[6 : 6+quantBlock/2]
+
+	out := make([]float32, quantBlock)
+	half := quantBlock / 2
+	for j := 0; j < half; j++ {
+		lo := int(qs[j]&0xF) | int((qh>>uint(j))&1)<<4
+		hi := int(qs[j]>>4) | int((qh>>uint(j+half))&1)<<4
+		out[j] = d * float32(lo-16)
+		out[j+half] = d * float32(hi-16)
+	}
+	return out
+}
+
+func dequantizeQ4_1Block(buf []byte) []float32 {
+	d := float16.Frombits(binary.LittleEndian.Uint16(buf[0:2])).Float32()
+	m := float16.Frombits(binary.LittleEndian.Uint16(buf[2:4])).Float32()
+	qs := buf[4 : 4+quantBlock/2]
+
+	out := make([]float32, quantBlock)
+	half := quantBlock / 2
+	for j := 0; j < half; j++ {
+		out[j] = d*float32(qs[j]&0xF) + m
+		out[j+half] = d*float32(qs[j]>>4) + m
+	}
+	return out
+}
+
+func dequantizeQ5_1Block(buf []byte) []float32 {
+	d := float16.Frombits(binary.LittleEndian.Uint16(buf[0:2])).Float32()
+	m := float16.Frombits(binary.LittleEndian.Uint16(buf[2:4])).Float32()
+	qh := binary.LittleEndian.Uint32(buf[4:8])
+	qs := buf[8 : 8+quantBlock/2]
+
+	out := make([]float32, quantBlock)
+	half := quantBlock / 2
+	for j := 0; j < half; j++ {
+		lo := int(qs[j]&0xF) | int((qh>>uint(j))&1)<<4
+		hi := int(qs[j]>>4) | int((qh>>uint(j+half))&1)<<4
+		out[j] = d*float32(lo) + m
+		out[j+half] = d*float32(hi) + m
+	}
+	return out
+}
+
+func dequantizeQ8_0Block(buf []byte) []float32 {
+	d := float16.Frombits(binary.LittleEndian.Uint16(buf[0:2])).Float32()
+	qs := buf[2 : 2+quantBlock]
+
+	out := make([]float32, quantBlock)
+	for j, q := range qs {
+		out[j] = d * float32(int8(q))
+	}
+	return out
+}
+
+// TestQuantizeDequantizeRoundTrip catches both arithmetic regressions (wrong
+// scale/zero-point) and layout regressions (elements packed into the wrong
+// nibble/byte) by decoding each encoder's output with an independent
+// decoder that follows ggml's documented block_q*_* layout.
+func TestQuantizeDequantizeRoundTrip(t *testing.T) {
+	block := make([]float32, quantBlock)
+	for i := range block {
+		block[i] = float32(i-16) * 0.37
+	}
+	// a couple of off-grid values so the peak isn't at block[0]/block[31]
+	block[3] = -5.2
+	block[20] = 8.9
+
+	cases := []struct {
+		name      string
+		encode    func([]float32) []byte
+		decode    func([]byte) []float32
+		tolerance float32
+	}{
+		{"Q8_0", quantizeQ8_0Block, dequantizeQ8_0Block, 0.05},
+		{"Q4_0", quantizeQ4_0Block, dequantizeQ4_0Block, 0.6},
+		{"Q5_0", quantizeQ5_0Block, dequantizeQ5_0Block, 0.3},
+		{"Q4_1", quantizeQ4_1Block, dequantizeQ4_1Block, 0.6},
+		{"Q5_1", quantizeQ5_1Block, dequantizeQ5_1Block, 0.3},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := c.decode(c.encode(block))
+			for i := range block {
+				if diff := float32(math.Abs(float64(got[i] - block[i]))); diff > c.tolerance {
+					t.Errorf("element %d: got %v, want %v (diff %v > tolerance %v)", i, got[i], block[i], diff, c.tolerance)
+				}
+			}
+		})
+	}
+}
+
+// TestPackNibblesLayout pins packNibbles to ggml's actual block layout:
+// element j in byte j's low nibble, element j+16 in byte j's high nibble,
+// rather than consecutive pairs (2i, 2i+1) sharing a byte.
+func TestPackNibblesLayout(t *testing.T) {
+	codes := make([]byte, quantBlock)
+	for i := range codes {
+		codes[i] = byte(i % 16)
+	}
+
+	packed := packNibbles(codes)
+	if len(packed) != quantBlock/2 {
+		t.Fatalf("got %d packed bytes, want %d", len(packed), quantBlock/2)
+	}
+
+	half := quantBlock / 2
+	for j := 0; j < half; j++ {
+		if lo := packed[j] & 0xF; lo != codes[j] {
+			t.Errorf("byte %d low nibble = %d, want element %d's code %d", j, lo, j, codes[j])
+		}
+		if hi := packed[j] >> 4; hi != codes[j+half] {
+			t.Errorf("byte %d high nibble = %d, want element %d's code %d", j, hi, j+half, codes[j+half])
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// tensorHeader is the slice of a safetensors header entry convert needs:
+// just enough to locate and interpret one tensor's bytes. Keeping this as
+// a small fixed struct instead of decoding the whole header into
+// map[string]interface{} is what lets parseSafetensorsHeader stream a
+// 70B-class model's header (tens of thousands of entries) without ever
+// holding it all as boxed interface{} values.
+type tensorHeader struct {
+	Name    string
+	Dtype   string `json:"dtype"`
+	Shape   []int  `json:"shape"`
+	Offsets [2]int `json:"data_offsets"`
+}
+
+// parseSafetensorsHeader streams a safetensors file's JSON header (r must
+// contain exactly the header bytes, not the trailing tensor data) into a
+// tensorHeader per real tensor, skipping "__metadata__" and any other
+// shape-less entry.
+func parseSafetensorsHeader(r io.Reader) ([]tensorHeader, error) {
+	d := json.NewDecoder(r)
+
+	tok, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("convert: malformed safetensors header")
+	}
+
+	var headers []tensorHeader
+	for d.More() {
+		keyTok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		name, ok := keyTok.(string)
+		if !ok {
+			return nil, fmt.Errorf("convert: malformed safetensors header")
+		}
+
+		if name == "__metadata__" {
+			var skip json.RawMessage
+			if err := d.Decode(&skip); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		var h tensorHeader
+		if err := d.Decode(&h); err != nil {
+			return nil, err
+		}
+
+		if len(h.Shape) == 0 {
+			// another metadata-shaped entry with no tensor data
+			continue
+		}
+
+		h.Name = name
+		headers = append(headers, h)
+	}
+
+	return headers, nil
+}
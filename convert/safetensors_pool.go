@@ -0,0 +1,155 @@
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+
+	"github.com/d4l3k/go-bfloat16"
+)
+
+// tensorResult is what a conversionPool worker hands back for one tensor:
+// its fully gguf-encoded bytes, ready to copy into the output file
+// verbatim, or the error that stopped it.
+type tensorResult struct {
+	data []byte
+	err  error
+}
+
+// conversionPool runs per-tensor bf16->gguf conversion on a bounded
+// number of goroutines (runtime.GOMAXPROCS(0) by default), so a 70B-class
+// model's thousands of tensors can convert concurrently without
+// serializing the whole model onto one core: only as many tensors as there
+// are workers are ever mid-conversion at a time. A finished tensor's bytes
+// stay buffered in its result channel until they are read, and workers
+// keep taking jobs regardless, so a caller that submits every tensor
+// before reading any result holds all of them converted in memory at once.
+type conversionPool struct {
+	jobs chan func() tensorResult
+}
+
+func newConversionPool() *conversionPool {
+	workers := max(1, runtime.GOMAXPROCS(0))
+	p := &conversionPool{jobs: make(chan func() tensorResult, 2*workers)}
+	for range workers {
+		go p.run()
+	}
+	return p
+}
+
+func (p *conversionPool) run() {
+	for job := range p.jobs {
+		job()
+	}
+}
+
+// submit queues fn to run on a pool worker and returns a channel that
+// receives its result exactly once, as soon as a worker is free to pick
+// it up. Conversion starts in the background immediately rather than
+// waiting for whoever reads the channel to ask for it.
+func (p *conversionPool) submit(fn func() ([]byte, error)) <-chan tensorResult {
+	result := make(chan tensorResult, 1)
+	p.jobs <- func() tensorResult {
+		data, err := fn()
+		r := tensorResult{data: data, err: err}
+		result <- r
+		return r
+	}
+	return result
+}
+
+// close stops accepting new jobs. Workers drain whatever's already queued
+// and then exit; it does not wait for that to happen, since the caller
+// reads every tensor's result from its own channel regardless.
+func (p *conversionPool) close() {
+	close(p.jobs)
+}
+
+// convertTensor applies name's architecture fix-up (streaming, via
+// TransformRaw, when arch supports it; materializing the tensor into
+// float32 otherwise) and writes the result through the bf16->gguf path,
+// buffered into memory so conversion can run independently of when the
+// gguf encoder actually asks the resulting safetensorWriterTo for bytes.
+func convertTensor(name string, raw []byte, shape []uint64, kind uint32, params *Params) ([]byte, error) {
+	arch, err := lookupArchitecture(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if !arch.Transforms(name) {
+		if err := writeChunked(&buf, params.ByteOrder, raw, kind); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	if sa, ok := arch.(StreamingArchitecture); ok {
+		rows, cols := int(shape[0]), 1
+		if len(shape) > 1 {
+			cols = int(shape[1])
+		}
+		if err := sa.TransformRaw(&buf, name, raw, rows, cols, params.ByteOrder, kind, params); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	slog.Debug(fmt.Sprintf("converting '%s'", name))
+
+	tDataF32 := bfloat16.DecodeFloat32(raw)
+	tDataF32, err = arch.TransformTensor(name, tDataF32, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeTensorData(&buf, params.ByteOrder, tDataF32, kind); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeChunked bf16-decodes and writes raw to w in fixed-size pieces, the
+// same bufSize this package always used for tensors an architecture
+// doesn't transform, so a single huge tensor never needs a second
+// full-size buffer alongside the bytes mmap already gives it for free.
+func writeChunked(w io.Writer, bo ByteOrder, raw []byte, kind uint32) error {
+	const bufSize = 10240
+
+	var carry []float32 // holds any block-quantized tail that spans a chunk boundary
+	for offset := 0; offset < len(raw); offset += bufSize {
+		end := min(offset+bufSize, len(raw))
+		finished := end == len(raw)
+
+		tDataF32 := bfloat16.DecodeFloat32(raw[offset:end])
+
+		if !isQuantizedKind(kind) {
+			if err := writeTensorData(w, bo, tDataF32, kind); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Quantized kinds must be written in whole quantBlock-sized
+		// groups, which chunk boundaries aren't guaranteed to land on,
+		// so buffer any partial block across chunks.
+		pending := append(carry, tDataF32...)
+		whole := len(pending) / quantBlock * quantBlock
+		if finished && whole < len(pending) {
+			// pad the tensor's final partial block with zeros
+			pending = append(pending, make([]float32, quantBlock-(len(pending)-whole))...)
+			whole = len(pending)
+		}
+
+		if err := writeTensorData(w, bo, pending[:whole], kind); err != nil {
+			return err
+		}
+		carry = append([]float32{}, pending[whole:]...)
+	}
+
+	return nil
+}
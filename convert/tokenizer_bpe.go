@@ -0,0 +1,151 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ollama/ollama/llm"
+)
+
+// bpeTokenizer is the slice of HuggingFace's fast-tokenizer tokenizer.json
+// this package needs: the BPE vocab and merge list, the added (special)
+// tokens, and enough of the pre-tokenizer config to identify it.
+type bpeTokenizer struct {
+	Model struct {
+		Type   string         `json:"type"`
+		Vocab  map[string]int `json:"vocab"`
+		Merges []string       `json:"merges"`
+	} `json:"model"`
+
+	AddedTokens []struct {
+		Id      int    `json:"id"`
+		Content string `json:"content"`
+		Special bool   `json:"special"`
+	} `json:"added_tokens"`
+
+	PreTokenizer struct {
+		PreTokenizers []struct {
+			Pattern struct {
+				Regex string `json:"Regex"`
+			} `json:"pattern"`
+		} `json:"pretokenizers"`
+	} `json:"pre_tokenizer"`
+}
+
+// preTokenizerFamilies maps a distinctive substring of a known
+// pre_tokenizer split regex to the llama.cpp pre-tokenizer family name
+// tokenizer.ggml.pre should carry, mirroring the regex table
+// convert-hf-to-gguf.py uses to tell BPE pre-tokenizers apart.
+var preTokenizerFamilies = []struct {
+	substr string
+	name   string
+}{
+	{`'(?i:[sdmt]|ll|ve|re)`, "llama-bpe"},
+	{`[\r\n]`, "deepseek-llm"},
+}
+
+// detectPreTokenizer returns the llama.cpp pre-tokenizer family name
+// matching tok's pre_tokenizer config, or "default" if none match.
+func detectPreTokenizer(tok *bpeTokenizer) string {
+	for _, p := range tok.PreTokenizer.PreTokenizers {
+		for _, family := range preTokenizerFamilies {
+			if strings.Contains(p.Pattern.Regex, family.substr) {
+				return family.name
+			}
+		}
+	}
+
+	return "default"
+}
+
+// loadBPEVocab reads a HuggingFace fast-tokenizer tokenizer.json and
+// builds the equivalent Vocab: tokens in id order, merge-rule scores
+// (-mergeRank for tokens a merge produces, 0 for the rest), and types
+// from added_tokens[].special.
+func loadBPEVocab(dirpath string, params *Params) (*Vocab, error) {
+	slog.Info(fmt.Sprintf("reading vocab from %s", filepath.Join(dirpath, "tokenizer.json")))
+	f, err := os.Open(filepath.Join(dirpath, "tokenizer.json"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tok bpeTokenizer
+	if err := json.NewDecoder(f).Decode(&tok); err != nil {
+		return nil, err
+	}
+
+	if tok.Model.Type != "BPE" {
+		return nil, fmt.Errorf("unsupported tokenizer.json model type %q", tok.Model.Type)
+	}
+
+	tokens := make([]string, len(tok.Model.Vocab))
+	for t, id := range tok.Model.Vocab {
+		if id < 0 || id >= len(tokens) {
+			return nil, fmt.Errorf("token ID '%d' for '%s' is out of range of vocab size %d", id, t, len(tokens))
+		}
+		tokens[id] = t
+	}
+
+	mergeRank := make(map[string]int, len(tok.Model.Merges))
+	for i, m := range tok.Model.Merges {
+		mergeRank[strings.ReplaceAll(m, " ", "")] = i
+	}
+
+	special := make(map[string]bool, len(tok.AddedTokens))
+	for _, t := range tok.AddedTokens {
+		special[t.Content] = t.Special
+	}
+
+	// added_tokens entries beyond len(tokens) (e.g. Qwen/Phi's chat and tool
+	// specials) aren't in model.vocab at all; merge them in by id the same
+	// way tokens itself was built, growing the slice as needed.
+	for _, t := range tok.AddedTokens {
+		if t.Id >= len(tokens) {
+			grown := make([]string, t.Id+1)
+			copy(grown, tokens)
+			tokens = grown
+		}
+		if tokens[t.Id] == "" {
+			tokens[t.Id] = t.Content
+		}
+	}
+
+	v := &Vocab{
+		Model:  "gpt2",
+		Tokens: tokens,
+		Scores: make([]float32, len(tokens)),
+		Types:  make([]int32, len(tokens)),
+		Merges: tok.Model.Merges,
+		Pre:    detectPreTokenizer(&tok),
+	}
+
+	for i, t := range tokens {
+		if rank, ok := mergeRank[t]; ok {
+			v.Scores[i] = -float32(rank)
+		}
+
+		v.Types[i] = int32(llm.GGUFTokenNormal)
+		if special[t] {
+			v.Types[i] = int32(llm.GGUFTokenControl)
+		}
+	}
+
+	slog.Info(fmt.Sprintf("vocab size: %d", len(v.Tokens)))
+
+	if params.VocabSize > len(v.Tokens) {
+		missingTokens := params.VocabSize - len(v.Tokens)
+		slog.Warn(fmt.Sprintf("vocab is missing %d tokens", missingTokens))
+		for cnt := 0; cnt < missingTokens; cnt++ {
+			v.Tokens = append(v.Tokens, fmt.Sprintf("<dummy%05d>", cnt+1))
+			v.Scores = append(v.Scores, -1)
+			v.Types = append(v.Types, int32(llm.GGUFTokenUserDefined))
+		}
+	}
+
+	return v, nil
+}
@@ -0,0 +1,107 @@
+//go:build !darwin
+
+package llm
+
+import (
+	"context"
+
+	"github.com/jmorganca/ollama/api"
+	"github.com/ollama/ollama/pkg/backend"
+)
+
+func init() {
+	backend.Register("llama", 0, func() (backend.Backend, error) {
+		return NewBackend(), nil
+	})
+}
+
+// NewBackend returns a backend.Backend backed by the cgo llama.cpp shim, for
+// use both by the in-process registry and by the standalone
+// cmd/grpc/llama worker binary.
+func NewBackend() backend.Backend {
+	return &shimBackend{}
+}
+
+// shimBackend adapts the existing cgo shimExtServer to the pluggable
+// backend.Backend interface, so it can be dispatched to from the
+// backend.Registry alongside the out-of-process gRPC workers under
+// cmd/grpc/<name>.
+type shimBackend struct {
+	library string
+	llm     extServer
+}
+
+func (b *shimBackend) LoadModel(ctx context.Context, path string, opts backend.ModelOpts) error {
+	modelOpts := api.Options{
+		ContextSize: opts.ContextSize,
+		Seed:        opts.Seed,
+		NGPULayers:  opts.NGPULayers,
+		MainGPU:     opts.MainGPU,
+		TensorSplit: opts.TensorSplit,
+		MMap:        opts.MMap,
+		MLock:       opts.MLock,
+		Embeddings:  opts.Embeddings,
+		NUMA:        opts.NUMA,
+		VocabOnly:   opts.VocabOnly,
+	}
+
+	llm, err := newDynamicShimExtServer(b.library, path, opts.Adapters, opts.Projectors, modelOpts)
+	if err != nil {
+		return err
+	}
+
+	b.llm = llm
+	return nil
+}
+
+func (b *shimBackend) Predict(ctx context.Context, opts backend.PredictOpts, fn func(backend.PredictResult)) error {
+	pred := PredictOpts{
+		Prompt:            opts.Prompt,
+		Seed:              opts.Seed,
+		Tokens:            opts.Tokens,
+		Threads:           opts.Threads,
+		TopK:              opts.TopK,
+		TopP:              opts.TopP,
+		TailFreeSamplingZ: opts.TailFreeSamplingZ,
+		TypicalP:          opts.TypicalP,
+		Temperature:       opts.Temperature,
+		Penalty:           opts.Penalty,
+		Repeat:            opts.Repeat,
+		FrequencyPenalty:  opts.FrequencyPenalty,
+		PresencePenalty:   opts.PresencePenalty,
+		Mirostat:          opts.Mirostat,
+		MirostatTAU:       opts.MirostatTAU,
+		MirostatETA:       opts.MirostatETA,
+		Batch:             opts.Batch,
+		NKeep:             opts.NKeep,
+	}
+
+	return b.llm.Predict(ctx, pred, func(r PredictResult) {
+		fn(backend.PredictResult{Token: r.Token, Done: r.Done})
+	})
+}
+
+func (b *shimBackend) Encode(ctx context.Context, prompt string) ([]int, error) {
+	return b.llm.Encode(ctx, prompt)
+}
+
+func (b *shimBackend) Decode(ctx context.Context, tokens []int) (string, error) {
+	return b.llm.Decode(ctx, tokens)
+}
+
+func (b *shimBackend) Tokenize(ctx context.Context, input string) ([]int, error) {
+	return b.llm.Encode(ctx, input)
+}
+
+func (b *shimBackend) Embedding(ctx context.Context, input string) ([]float64, error) {
+	return b.llm.Embedding(ctx, input)
+}
+
+func (b *shimBackend) Health(ctx context.Context) error {
+	return nil
+}
+
+func (b *shimBackend) Close() error {
+	b.llm.Close()
+	return nil
+}
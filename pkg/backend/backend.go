@@ -0,0 +1,95 @@
+// Package backend defines the pluggable model runtime used by the ollama
+// server. Each supported model family (llama, falcon/ggllm, gpt4all, bert,
+// starcoder, ...) implements Backend. pkg/backend/worker defines a worker
+// protocol that serves a Backend from an out-of-process binary under
+// cmd/grpc/<name>, and a Client that forwards calls to such a worker. The
+// backends registered with Register run inside the server process, and
+// nothing in this package starts or dials a worker.
+package backend
+
+import "context"
+
+// PredictOpts carries the sampling and context parameters for a single
+// completion request. It intentionally mirrors the shape of the existing
+// llama.PredictOptions so callers can translate between the two with a
+// straight field copy.
+type PredictOpts struct {
+	Prompt            string
+	Seed              int
+	Tokens            int
+	Threads           int
+	TopK              int
+	TopP              float64
+	TailFreeSamplingZ float64
+	TypicalP          float64
+	Temperature       float64
+	Penalty           float64
+	Repeat            int
+	FrequencyPenalty  float64
+	PresencePenalty   float64
+	Mirostat          int
+	MirostatTAU       float64
+	MirostatETA       float64
+	Batch             int
+	NKeep             int
+	Stop              []string
+}
+
+// PredictResult is a single streamed token produced by Predict; Done is set
+// on the final call. It carries no timing information.
+type PredictResult struct {
+	Token string
+	Done  bool
+}
+
+// Backend is implemented by every model family. Implementations either run
+// in-process (legacy shim) or as a client that forwards each call over the
+// worker protocol to an out-of-process binary under cmd/grpc/<name>.
+type Backend interface {
+	// LoadModel prepares the backend to serve the model at path with the
+	// given options. It must be called before Predict/Encode/Decode/Embedding.
+	LoadModel(ctx context.Context, path string, opts ModelOpts) error
+
+	Predict(ctx context.Context, opts PredictOpts, fn func(PredictResult)) error
+	Encode(ctx context.Context, prompt string) ([]int, error)
+	Decode(ctx context.Context, tokens []int) (string, error)
+	Embedding(ctx context.Context, input string) ([]float64, error)
+	Tokenize(ctx context.Context, input string) ([]int, error)
+
+	// Health reports whether the backend is alive and able to serve
+	// requests. The worker client uses this to decide when to reconnect.
+	Health(ctx context.Context) error
+
+	Close() error
+}
+
+// Transcriber is implemented by backends that can turn audio into text
+// (e.g. the whisper.cpp worker under cmd/grpc/whisper). It's a separate,
+// optional interface rather than part of Backend because most backends
+// (llama, bert, ...) have no audio capability at all.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio []byte) (string, error)
+}
+
+// Synthesizer is implemented by backends that can turn text into audio
+// (e.g. the piper worker under cmd/grpc/piper).
+type Synthesizer interface {
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+}
+
+// ModelOpts carries the subset of model-load parameters that are common
+// across backends (GPU layers, context size, memory mapping, ...).
+type ModelOpts struct {
+	ContextSize int
+	Seed        int
+	NGPULayers  int
+	MainGPU     string
+	TensorSplit string
+	MMap        bool
+	MLock       bool
+	Embeddings  bool
+	NUMA        bool
+	VocabOnly   bool
+	Adapters    []string
+	Projectors  []string
+}
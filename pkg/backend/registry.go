@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Loader constructs a new, unloaded Backend for a given model family. The
+// returned Backend still needs LoadModel called on it.
+type Loader func() (Backend, error)
+
+// registration pairs a Loader with the priority it should be tried at when
+// autoloading (lower runs first).
+type registration struct {
+	name     string
+	loader   Loader
+	priority int
+}
+
+var (
+	mu      sync.RWMutex
+	loaders = map[string]registration{}
+)
+
+// Register makes a backend available under name, to be selected either
+// directly by name (Get) or as part of autoload fallback (Autoload).
+// Neither inspects the model file to detect its architecture.
+// Backends are expected to call this from an init() in the package that
+// implements them, mirroring the convert.Register pattern used for GGUF
+// architectures.
+func Register(name string, priority int, loader Loader) {
+	mu.Lock()
+	defer mu.Unlock()
+	loaders[name] = registration{name: name, loader: loader, priority: priority}
+}
+
+// Get returns a fresh Backend registered under name.
+func Get(name string) (Backend, error) {
+	mu.RLock()
+	reg, ok := loaders[name]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("backend: no backend registered for %q", name)
+	}
+	return reg.loader()
+}
+
+// Autoload tries every registered backend in priority order, loading model
+// at path with opts, and returns the first one that succeeds. It's used when
+// the model format doesn't unambiguously identify an architecture.
+func Autoload(ctx context.Context, path string, opts ModelOpts) (Backend, error) {
+	mu.RLock()
+	regs := make([]registration, 0, len(loaders))
+	for _, r := range loaders {
+		regs = append(regs, r)
+	}
+	mu.RUnlock()
+
+	sort.Slice(regs, func(i, j int) bool { return regs[i].priority < regs[j].priority })
+
+	var errs []error
+	for _, r := range regs {
+		b, err := r.loader()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+			continue
+		}
+
+		if err := b.LoadModel(ctx, path, opts); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.name, err))
+			b.Close()
+			continue
+		}
+
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("backend: autoload: no backend could load %q: %v", path, errs)
+}
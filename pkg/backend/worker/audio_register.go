@@ -0,0 +1,12 @@
+package worker
+
+import "github.com/ollama/ollama/pkg/backend"
+
+func init() {
+	backend.Register("whisper", 10, func() (backend.Backend, error) {
+		return NewWhisperCLI(), nil
+	})
+	backend.Register("piper", 11, func() (backend.Backend, error) {
+		return NewPiperCLI(), nil
+	})
+}
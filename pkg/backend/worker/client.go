@@ -0,0 +1,209 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/ollama/ollama/pkg/backend"
+	pb "github.com/ollama/ollama/pkg/backend/worker/proto"
+)
+
+// reconnectBackoff is the delay between health-check failures before the
+// client attempts to redial the worker's unix socket.
+const reconnectBackoff = 500 * time.Millisecond
+
+// Client is a backend.Backend that forwards every call to an out-of-process
+// worker binary (see cmd/grpc/<name>) over a unix socket.
+type Client struct {
+	sockPath string
+	conn     *grpc.ClientConn
+	rpc      pb.WorkerClient
+}
+
+// Dial connects to a worker already listening on sockPath.
+func Dial(ctx context.Context, sockPath string) (*Client, error) {
+	c := &Client{sockPath: sockPath}
+	if err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) connect(ctx context.Context) error {
+	conn, err := grpc.DialContext(ctx, "unix:"+c.sockPath,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", c.sockPath)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("worker: dial %s: %w", c.sockPath, err)
+	}
+
+	c.conn = conn
+	c.rpc = pb.NewWorkerClient(conn)
+	return nil
+}
+
+// reconnectIfNeeded pings Health and redials once if the worker has gone
+// away, e.g. after a crash-and-restart of the out-of-process binary.
+func (c *Client) reconnectIfNeeded(ctx context.Context) error {
+	if _, err := c.rpc.Health(ctx, &pb.HealthRequest{}); err != nil {
+		slog.Warn("worker: health check failed, reconnecting", "socket", c.sockPath, "error", err)
+		time.Sleep(reconnectBackoff)
+		return c.connect(ctx)
+	}
+	return nil
+}
+
+func (c *Client) LoadModel(ctx context.Context, path string, opts backend.ModelOpts) error {
+	resp, err := c.rpc.LoadModel(ctx, &pb.LoadModelRequest{
+		Path:        path,
+		ContextSize: int32(opts.ContextSize),
+		Seed:        int32(opts.Seed),
+		NGpuLayers:  int32(opts.NGPULayers),
+		MainGpu:     opts.MainGPU,
+		TensorSplit: opts.TensorSplit,
+		Mmap:        opts.MMap,
+		Mlock:       opts.MLock,
+		Embeddings:  opts.Embeddings,
+		Numa:        opts.NUMA,
+		VocabOnly:   opts.VocabOnly,
+		Adapters:    opts.Adapters,
+		Projectors:  opts.Projectors,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("worker: load model: %s", resp.Error)
+	}
+	return nil
+}
+
+func (c *Client) Predict(ctx context.Context, opts backend.PredictOpts, fn func(backend.PredictResult)) error {
+	if err := c.reconnectIfNeeded(ctx); err != nil {
+		return err
+	}
+
+	stream, err := c.rpc.Predict(ctx, &pb.PredictRequest{
+		Prompt:            opts.Prompt,
+		Seed:              int32(opts.Seed),
+		Tokens:            int32(opts.Tokens),
+		Threads:           int32(opts.Threads),
+		TopK:              int32(opts.TopK),
+		TopP:              opts.TopP,
+		TailFreeSamplingZ: opts.TailFreeSamplingZ,
+		TypicalP:          opts.TypicalP,
+		Temperature:       opts.Temperature,
+		Penalty:           opts.Penalty,
+		Repeat:            int32(opts.Repeat),
+		FrequencyPenalty:  opts.FrequencyPenalty,
+		PresencePenalty:   opts.PresencePenalty,
+		Mirostat:          int32(opts.Mirostat),
+		MirostatTau:       opts.MirostatTAU,
+		MirostatEta:       opts.MirostatETA,
+		Batch:             int32(opts.Batch),
+		NKeep:             int32(opts.NKeep),
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fn(backend.PredictResult{Token: reply.Token, Done: reply.Done})
+		if reply.Done {
+			return nil
+		}
+	}
+}
+
+func (c *Client) Encode(ctx context.Context, prompt string) ([]int, error) {
+	resp, err := c.rpc.Tokenize(ctx, &pb.TokenizeRequest{Input: prompt})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]int, len(resp.Tokens))
+	for i, t := range resp.Tokens {
+		tokens[i] = int(t)
+	}
+	return tokens, nil
+}
+
+func (c *Client) Decode(ctx context.Context, tokens []int) (string, error) {
+	pbTokens := make([]int32, len(tokens))
+	for i, t := range tokens {
+		pbTokens[i] = int32(t)
+	}
+
+	resp, err := c.rpc.Tokenize(ctx, &pb.TokenizeRequest{Tokens: pbTokens})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+func (c *Client) Tokenize(ctx context.Context, input string) ([]int, error) {
+	return c.Encode(ctx, input)
+}
+
+func (c *Client) Embedding(ctx context.Context, input string) ([]float64, error) {
+	resp, err := c.rpc.Embedding(ctx, &pb.EmbeddingRequest{Input: input})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Embedding, nil
+}
+
+func (c *Client) Health(ctx context.Context) error {
+	resp, err := c.rpc.Health(ctx, &pb.HealthRequest{})
+	if err != nil {
+		return err
+	}
+	if !resp.Alive {
+		return fmt.Errorf("worker: %s reports not alive", c.sockPath)
+	}
+	return nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Transcribe forwards to the worker's Transcribe RPC. It's only meaningful
+// against an audio worker (cmd/grpc/whisper); other workers return an
+// error, which callers should treat as "not an audio backend".
+func (c *Client) Transcribe(ctx context.Context, audio []byte) (string, error) {
+	resp, err := c.rpc.Transcribe(ctx, &pb.TranscribeRequest{Audio: audio})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// Synthesize forwards to the worker's Synthesize RPC, meaningful against a
+// TTS worker (cmd/grpc/piper).
+func (c *Client) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	resp, err := c.rpc.Synthesize(ctx, &pb.SynthesizeRequest{Text: text})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Audio, nil
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ollama/ollama/pkg/backend"
+)
+
+// PiperCLI is a backend.Backend that synthesizes speech by shelling out to
+// the piper CLI binary, rather than binding its inference code in-process.
+// The binary defaults to "piper" on PATH and can be overridden with the
+// OLLAMA_PIPER_CLI environment variable. Everything but LoadModel,
+// Synthesize, Health, and Close is unsupported.
+type PiperCLI struct {
+	bin       string
+	modelPath string
+}
+
+// NewPiperCLI returns an unloaded PiperCLI backend.
+func NewPiperCLI() *PiperCLI {
+	bin := os.Getenv("OLLAMA_PIPER_CLI")
+	if bin == "" {
+		bin = "piper"
+	}
+	return &PiperCLI{bin: bin}
+}
+
+func (p *PiperCLI) LoadModel(ctx context.Context, path string, opts backend.ModelOpts) error {
+	p.modelPath = path
+	return nil
+}
+
+func (p *PiperCLI) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	if p.modelPath == "" {
+		return nil, fmt.Errorf("piper: LoadModel must be called before Synthesize")
+	}
+
+	out, err := os.CreateTemp("", "ollama-piper-out-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("piper: %w", err)
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	cmd := exec.CommandContext(ctx, p.bin, "-m", p.modelPath, "-f", out.Name())
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("piper: %s: %w", strings.TrimSpace(stderr.String()), err)
+	}
+
+	wav, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, fmt.Errorf("piper: reading synthesized audio: %w", err)
+	}
+
+	return wav, nil
+}
+
+func (p *PiperCLI) Predict(ctx context.Context, opts backend.PredictOpts, fn func(backend.PredictResult)) error {
+	return fmt.Errorf("piper: text completion is not supported, use Synthesize")
+}
+
+func (p *PiperCLI) Encode(ctx context.Context, prompt string) ([]int, error) {
+	return nil, fmt.Errorf("piper: Encode is not supported")
+}
+
+func (p *PiperCLI) Decode(ctx context.Context, tokens []int) (string, error) {
+	return "", fmt.Errorf("piper: Decode is not supported")
+}
+
+func (p *PiperCLI) Tokenize(ctx context.Context, input string) ([]int, error) {
+	return nil, fmt.Errorf("piper: Tokenize is not supported")
+}
+
+func (p *PiperCLI) Embedding(ctx context.Context, input string) ([]float64, error) {
+	return nil, fmt.Errorf("piper: Embedding is not supported")
+}
+
+func (p *PiperCLI) Health(ctx context.Context) error {
+	return nil
+}
+
+func (p *PiperCLI) Close() error {
+	return nil
+}
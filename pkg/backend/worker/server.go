@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+
+	"github.com/ollama/ollama/pkg/backend"
+	pb "github.com/ollama/ollama/pkg/backend/worker/proto"
+)
+
+// Serve starts a gRPC server on a unix socket at sockPath, dispatching every
+// call to impl. It's the common main() body shared by every binary under
+// cmd/grpc/<name>; each one only needs to supply a backend.Backend
+// implementation for its model family.
+func Serve(sockPath string, impl backend.Backend) error {
+	if err := os.RemoveAll(sockPath); err != nil {
+		return fmt.Errorf("worker: remove stale socket: %w", err)
+	}
+
+	lis, err := net.Listen("unix", sockPath)
+	if err != nil {
+		return fmt.Errorf("worker: listen on %s: %w", sockPath, err)
+	}
+
+	s := grpc.NewServer()
+	pb.RegisterWorkerServer(s, &server{impl: impl})
+
+	slog.Info("worker: listening", "socket", sockPath)
+	return s.Serve(lis)
+}
+
+type server struct {
+	pb.UnimplementedWorkerServer
+	impl backend.Backend
+}
+
+func (s *server) LoadModel(ctx context.Context, req *pb.LoadModelRequest) (*pb.LoadModelResponse, error) {
+	opts := backend.ModelOpts{
+		ContextSize: int(req.ContextSize),
+		Seed:        int(req.Seed),
+		NGPULayers:  int(req.NGpuLayers),
+		MainGPU:     req.MainGpu,
+		TensorSplit: req.TensorSplit,
+		MMap:        req.Mmap,
+		MLock:       req.Mlock,
+		Embeddings:  req.Embeddings,
+		NUMA:        req.Numa,
+		VocabOnly:   req.VocabOnly,
+		Adapters:    req.Adapters,
+		Projectors:  req.Projectors,
+	}
+
+	if err := s.impl.LoadModel(ctx, req.Path, opts); err != nil {
+		return &pb.LoadModelResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &pb.LoadModelResponse{Success: true}, nil
+}
+
+func (s *server) Predict(req *pb.PredictRequest, stream pb.Worker_PredictServer) error {
+	opts := backend.PredictOpts{
+		Prompt:            req.Prompt,
+		Seed:              int(req.Seed),
+		Tokens:            int(req.Tokens),
+		Threads:           int(req.Threads),
+		TopK:              int(req.TopK),
+		TopP:              req.TopP,
+		TailFreeSamplingZ: req.TailFreeSamplingZ,
+		TypicalP:          req.TypicalP,
+		Temperature:       req.Temperature,
+		Penalty:           req.Penalty,
+		Repeat:            int(req.Repeat),
+		FrequencyPenalty:  req.FrequencyPenalty,
+		PresencePenalty:   req.PresencePenalty,
+		Mirostat:          int(req.Mirostat),
+		MirostatTAU:       req.MirostatTau,
+		MirostatETA:       req.MirostatEta,
+		Batch:             int(req.Batch),
+		NKeep:             int(req.NKeep),
+	}
+
+	return s.impl.Predict(stream.Context(), opts, func(r backend.PredictResult) {
+		stream.Send(&pb.PredictReply{Token: r.Token, Done: r.Done})
+	})
+}
+
+func (s *server) Embedding(ctx context.Context, req *pb.EmbeddingRequest) (*pb.EmbeddingReply, error) {
+	emb, err := s.impl.Embedding(ctx, req.Input)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.EmbeddingReply{Embedding: emb}, nil
+}
+
+func (s *server) Tokenize(ctx context.Context, req *pb.TokenizeRequest) (*pb.TokenizeReply, error) {
+	if len(req.Tokens) > 0 {
+		tokens := make([]int, len(req.Tokens))
+		for i, t := range req.Tokens {
+			tokens[i] = int(t)
+		}
+
+		text, err := s.impl.Decode(ctx, tokens)
+		if err != nil {
+			return nil, err
+		}
+		return &pb.TokenizeReply{Text: text}, nil
+	}
+
+	tokens, err := s.impl.Tokenize(ctx, req.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	pbTokens := make([]int32, len(tokens))
+	for i, t := range tokens {
+		pbTokens[i] = int32(t)
+	}
+	return &pb.TokenizeReply{Tokens: pbTokens}, nil
+}
+
+func (s *server) Health(ctx context.Context, req *pb.HealthRequest) (*pb.HealthReply, error) {
+	if err := s.impl.Health(ctx); err != nil {
+		return &pb.HealthReply{Alive: false}, nil
+	}
+	return &pb.HealthReply{Alive: true}, nil
+}
+
+func (s *server) Transcribe(ctx context.Context, req *pb.TranscribeRequest) (*pb.TranscribeReply, error) {
+	t, ok := s.impl.(backend.Transcriber)
+	if !ok {
+		return nil, fmt.Errorf("worker: backend does not support transcription")
+	}
+
+	text, err := t.Transcribe(ctx, req.Audio)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TranscribeReply{Text: text}, nil
+}
+
+func (s *server) Synthesize(ctx context.Context, req *pb.SynthesizeRequest) (*pb.SynthesizeReply, error) {
+	t, ok := s.impl.(backend.Synthesizer)
+	if !ok {
+		return nil, fmt.Errorf("worker: backend does not support speech synthesis")
+	}
+
+	audio, err := t.Synthesize(ctx, req.Text)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SynthesizeReply{Audio: audio}, nil
+}
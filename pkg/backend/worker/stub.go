@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ollama/ollama/pkg/backend"
+)
+
+// NotImplemented is a backend.Backend placeholder for model families whose
+// worker binary exists but whose inference code hasn't been wired up yet. It
+// lets cmd/grpc/<name> stand up the socket and answer Health checks while the
+// real bindings land in a follow-up change.
+type NotImplemented struct {
+	Name string
+}
+
+func (n *NotImplemented) err() error {
+	return fmt.Errorf("%s: backend not yet implemented", n.Name)
+}
+
+func (n *NotImplemented) LoadModel(ctx context.Context, path string, opts backend.ModelOpts) error {
+	return n.err()
+}
+
+func (n *NotImplemented) Predict(ctx context.Context, opts backend.PredictOpts, fn func(backend.PredictResult)) error {
+	return n.err()
+}
+
+func (n *NotImplemented) Encode(ctx context.Context, prompt string) ([]int, error) {
+	return nil, n.err()
+}
+
+func (n *NotImplemented) Decode(ctx context.Context, tokens []int) (string, error) {
+	return "", n.err()
+}
+
+func (n *NotImplemented) Tokenize(ctx context.Context, input string) ([]int, error) {
+	return nil, n.err()
+}
+
+func (n *NotImplemented) Embedding(ctx context.Context, input string) ([]float64, error) {
+	return nil, n.err()
+}
+
+func (n *NotImplemented) Health(ctx context.Context) error {
+	return nil
+}
+
+func (n *NotImplemented) Transcribe(ctx context.Context, audio []byte) (string, error) {
+	return "", n.err()
+}
+
+func (n *NotImplemented) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	return nil, n.err()
+}
+
+func (n *NotImplemented) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ollama/ollama/pkg/backend"
+)
+
+// WhisperCLI is a backend.Backend that transcribes audio by shelling out to
+// whisper.cpp's CLI binary, rather than binding its C++ inference code
+// in-process the way llm's shimExtServer does for llama.cpp. The binary
+// defaults to "whisper-cli" on PATH and can be overridden with the
+// OLLAMA_WHISPER_CLI environment variable. Everything but LoadModel,
+// Transcribe, Health, and Close is unsupported.
+type WhisperCLI struct {
+	bin       string
+	modelPath string
+}
+
+// NewWhisperCLI returns an unloaded WhisperCLI backend.
+func NewWhisperCLI() *WhisperCLI {
+	bin := os.Getenv("OLLAMA_WHISPER_CLI")
+	if bin == "" {
+		bin = "whisper-cli"
+	}
+	return &WhisperCLI{bin: bin}
+}
+
+func (w *WhisperCLI) LoadModel(ctx context.Context, path string, opts backend.ModelOpts) error {
+	w.modelPath = path
+	return nil
+}
+
+func (w *WhisperCLI) Transcribe(ctx context.Context, audio []byte) (string, error) {
+	if w.modelPath == "" {
+		return "", fmt.Errorf("whisper: LoadModel must be called before Transcribe")
+	}
+
+	in, err := os.CreateTemp("", "ollama-whisper-in-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("whisper: %w", err)
+	}
+	defer os.Remove(in.Name())
+
+	if _, err := in.Write(audio); err != nil {
+		in.Close()
+		return "", fmt.Errorf("whisper: writing input audio: %w", err)
+	}
+	if err := in.Close(); err != nil {
+		return "", fmt.Errorf("whisper: writing input audio: %w", err)
+	}
+
+	outPrefix := in.Name()
+	defer os.Remove(outPrefix + ".txt")
+
+	cmd := exec.CommandContext(ctx, w.bin, "-m", w.modelPath, "-f", in.Name(), "-otxt", "-of", outPrefix, "-np", "-nt")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("whisper: %s: %w", strings.TrimSpace(stderr.String()), err)
+	}
+
+	text, err := os.ReadFile(outPrefix + ".txt")
+	if err != nil {
+		return "", fmt.Errorf("whisper: reading transcript: %w", err)
+	}
+
+	return strings.TrimSpace(string(text)), nil
+}
+
+func (w *WhisperCLI) Predict(ctx context.Context, opts backend.PredictOpts, fn func(backend.PredictResult)) error {
+	return fmt.Errorf("whisper: text completion is not supported, use Transcribe")
+}
+
+func (w *WhisperCLI) Encode(ctx context.Context, prompt string) ([]int, error) {
+	return nil, fmt.Errorf("whisper: Encode is not supported")
+}
+
+func (w *WhisperCLI) Decode(ctx context.Context, tokens []int) (string, error) {
+	return "", fmt.Errorf("whisper: Decode is not supported")
+}
+
+func (w *WhisperCLI) Tokenize(ctx context.Context, input string) ([]int, error) {
+	return nil, fmt.Errorf("whisper: Tokenize is not supported")
+}
+
+func (w *WhisperCLI) Embedding(ctx context.Context, input string) ([]float64, error) {
+	return nil, fmt.Errorf("whisper: Embedding is not supported")
+}
+
+func (w *WhisperCLI) Health(ctx context.Context) error {
+	return nil
+}
+
+func (w *WhisperCLI) Close() error {
+	return nil
+}
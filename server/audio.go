@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ollama/ollama/pkg/backend"
+)
+
+// transcribeProgress is streamed to the client the same way pull streams
+// api.PullProgress: one JSON object per line, with a final entry carrying
+// the result.
+type transcribeProgress struct {
+	Status string `json:"status"`
+	Text   string `json:"text,omitempty"`
+}
+
+func transcribe(c *gin.Context) {
+	model := c.PostForm("model")
+	if model == "" {
+		model = "whisper"
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing multipart field \"file\""})
+		return
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	audio, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	progressCh := make(chan transcribeProgress)
+	go func() {
+		defer close(progressCh)
+
+		progressCh <- transcribeProgress{Status: "loading model"}
+
+		handle, err := modelPool.Acquire(c.Request.Context(), model, backend.ModelOpts{})
+		if err != nil {
+			progressCh <- transcribeProgress{Status: "error: " + err.Error()}
+			return
+		}
+		defer handle.Release()
+
+		t, ok := handle.Backend().(backend.Transcriber)
+		if !ok {
+			progressCh <- transcribeProgress{Status: "error: model does not support transcription"}
+			return
+		}
+
+		progressCh <- transcribeProgress{Status: "transcribing"}
+		text, err := t.Transcribe(c.Request.Context(), audio)
+		if err != nil {
+			progressCh <- transcribeProgress{Status: "error: " + err.Error()}
+			return
+		}
+
+		progressCh <- transcribeProgress{Status: "success", Text: text}
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		progress, ok := <-progressCh
+		if !ok {
+			return false
+		}
+
+		bts, err := json.Marshal(progress)
+		if err != nil {
+			return false
+		}
+
+		bts = append(bts, '\n')
+		if _, err := w.Write(bts); err != nil {
+			return false
+		}
+
+		return true
+	})
+}
+
+// ttsProgress mirrors transcribeProgress for the /api/tts endpoint; the
+// synthesized audio is base64-encoded in the final event.
+type ttsProgress struct {
+	Status string `json:"status"`
+	Audio  string `json:"audio,omitempty"` // base64-encoded wav
+}
+
+func tts(c *gin.Context) {
+	var req struct {
+		Model string `json:"model"`
+		Text  string `json:"text"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Model == "" {
+		req.Model = "piper"
+	}
+
+	progressCh := make(chan ttsProgress)
+	go func() {
+		defer close(progressCh)
+
+		progressCh <- ttsProgress{Status: "loading model"}
+
+		handle, err := modelPool.Acquire(c.Request.Context(), req.Model, backend.ModelOpts{})
+		if err != nil {
+			progressCh <- ttsProgress{Status: "error: " + err.Error()}
+			return
+		}
+		defer handle.Release()
+
+		s, ok := handle.Backend().(backend.Synthesizer)
+		if !ok {
+			progressCh <- ttsProgress{Status: "error: model does not support speech synthesis"}
+			return
+		}
+
+		progressCh <- ttsProgress{Status: "synthesizing"}
+		audio, err := s.Synthesize(c.Request.Context(), req.Text)
+		if err != nil {
+			progressCh <- ttsProgress{Status: "error: " + err.Error()}
+			return
+		}
+
+		progressCh <- ttsProgress{Status: "success", Audio: base64.StdEncoding.EncodeToString(audio)}
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		progress, ok := <-progressCh
+		if !ok {
+			return false
+		}
+
+		bts, err := json.Marshal(progress)
+		if err != nil {
+			return false
+		}
+
+		bts = append(bts, '\n')
+		if _, err := w.Write(bts); err != nil {
+			return false
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,245 @@
+// Package gallery implements a catalog of installable models. A gallery is
+// a YAML index, served from a local path or an HTTP(S) URL, listing GGUF
+// downloads along with the metadata needed to install them: checksum,
+// prompt template, default options, and which backend should serve them.
+package gallery
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmorganca/ollama/api"
+	"github.com/ollama/ollama/server/modelconfig"
+)
+
+// Entry is a single installable model in a gallery index.
+type Entry struct {
+	Name           string              `yaml:"name" json:"name"`
+	Description    string              `yaml:"description" json:"description"`
+	License        string              `yaml:"license" json:"license"`
+	URL            string              `yaml:"url" json:"url"`
+	SHA256         string              `yaml:"sha256" json:"sha256"`
+	PromptTemplate string              `yaml:"prompt_template" json:"prompt_template"`
+	Backend        string              `yaml:"backend" json:"backend"`
+	DefaultOptions *api.PredictOptions `yaml:"default_options,omitempty" json:"default_options,omitempty"`
+}
+
+// Index is the parsed form of a gallery's YAML document.
+type Index struct {
+	Entries []Entry `yaml:"entries"`
+}
+
+// galleries holds the configured gallery sources (local paths or URLs), set
+// at init from the OLLAMA_GALLERIES env var (comma-separated) or later by
+// calling SetSources. No command-line flag sets it.
+var galleries []string
+
+func init() {
+	if v := os.Getenv("OLLAMA_GALLERIES"); v != "" {
+		SetSources(strings.Split(v, ","))
+	}
+}
+
+// SetSources replaces the configured gallery sources.
+func SetSources(sources []string) {
+	galleries = nil
+	for _, s := range sources {
+		if s = strings.TrimSpace(s); s != "" {
+			galleries = append(galleries, s)
+		}
+	}
+}
+
+// Sources returns the currently configured gallery sources.
+func Sources() []string {
+	return galleries
+}
+
+// LoadIndex reads and parses a gallery index from a local path or an
+// http(s) URL.
+func LoadIndex(source string) (*Index, error) {
+	var b []byte
+	var err error
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, rerr := http.Get(source)
+		if rerr != nil {
+			return nil, fmt.Errorf("gallery: fetching %s: %w", source, rerr)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("gallery: fetching %s: status %s", source, resp.Status)
+		}
+
+		b, err = io.ReadAll(resp.Body)
+	} else {
+		b, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gallery: reading %s: %w", source, err)
+	}
+
+	var idx Index
+	if err := yaml.Unmarshal(b, &idx); err != nil {
+		return nil, fmt.Errorf("gallery: parsing %s: %w", source, err)
+	}
+
+	return &idx, nil
+}
+
+// List aggregates entries across every configured gallery.
+func List() ([]Entry, error) {
+	var entries []Entry
+	for _, src := range galleries {
+		idx, err := LoadIndex(src)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, idx.Entries...)
+	}
+	return entries, nil
+}
+
+// find looks up name across every configured gallery.
+func find(name string) (*Entry, error) {
+	entries, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.Name == name {
+			return &e, nil
+		}
+	}
+
+	return nil, fmt.Errorf("gallery: no entry named %q in configured galleries", name)
+}
+
+// Progress mirrors api.PullProgress so gallery installs stream the same
+// shape of event the existing pull handler does.
+type Progress struct {
+	Status string `json:"status"`
+}
+
+// modelsDir is ~/.ollama/models.
+func modelsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".ollama", "models"), nil
+}
+
+// Apply installs the gallery entry named name: it downloads the GGUF to
+// ~/.ollama/models, verifies its sha256, and writes the per-model YAML
+// config modelconfig.Resolve will later pick up.
+func Apply(name string, progressCh chan<- Progress) error {
+	entry, err := find(name)
+	if err != nil {
+		return err
+	}
+
+	dir, err := modelsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	progressCh <- Progress{Status: fmt.Sprintf("downloading %s", entry.URL)}
+
+	modelPath := filepath.Join(dir, entry.Name+".gguf")
+	if err := download(entry.URL, modelPath, entry.SHA256); err != nil {
+		return err
+	}
+
+	progressCh <- Progress{Status: "writing model config"}
+
+	cfg := modelconfig.Config{
+		Backend:        entry.Backend,
+		Template:       modelconfig.Template{Body: entry.PromptTemplate},
+		PredictOptions: entry.DefaultOptions,
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	cfgPath := filepath.Join(dir, entry.Name+".yaml")
+	if err := os.WriteFile(cfgPath, b, 0o644); err != nil {
+		return err
+	}
+
+	progressCh <- Progress{Status: "success"}
+	return nil
+}
+
+// download fetches url to dest, verifying the result against wantSHA256
+// (hex-encoded) if one was provided.
+func download(url, dest, wantSHA256 string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gallery: downloading %s: status %s", url, resp.Status)
+	}
+
+	tmp := dest + ".partial"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	f.Close()
+
+	if wantSHA256 != "" {
+		got := hex.EncodeToString(h.Sum(nil))
+		if !strings.EqualFold(got, wantSHA256) {
+			os.Remove(tmp)
+			return fmt.Errorf("gallery: sha256 mismatch for %s: got %s, want %s", url, got, wantSHA256)
+		}
+	}
+
+	return os.Rename(tmp, dest)
+}
+
+// Installed lists the models already present in ~/.ollama/models, by the
+// GGUF files found there (one entry per <name>.gguf).
+func Installed() ([]string, error) {
+	dir, err := modelsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.gguf"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".gguf"))
+	}
+	return names, nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ollama/ollama/server/gallery"
+)
+
+func galleryList(c *gin.Context) {
+	entries, err := gallery.List()
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"models": entries})
+}
+
+func galleryApply(c *gin.Context) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	progressCh := make(chan gallery.Progress)
+	go func() {
+		defer close(progressCh)
+		if err := gallery.Apply(req.Name, progressCh); err != nil {
+			progressCh <- gallery.Progress{Status: "error: " + err.Error()}
+		}
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		progress, ok := <-progressCh
+		if !ok {
+			return false
+		}
+
+		bts, err := json.Marshal(progress)
+		if err != nil {
+			return false
+		}
+
+		bts = append(bts, '\n')
+		if _, err := w.Write(bts); err != nil {
+			return false
+		}
+
+		return true
+	})
+}
+
+func listModels(c *gin.Context) {
+	names, err := gallery.Installed()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"models": names})
+}
@@ -0,0 +1,253 @@
+// Package modelconfig resolves a model name to its template, stop tokens,
+// and default options, replacing the old fuzzy match over templates/*.prompt
+// filenames. Configs are loaded from ~/.ollama/models/<name>.yaml, falling
+// back to a small set of embedded defaults for well-known model families.
+package modelconfig
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmorganca/ollama/api"
+)
+
+//go:embed defaults/*.yaml
+var defaultsFS embed.FS
+
+// Template holds the Modelfile-style prompt template for a model, plus the
+// per-role variants used when building chat completions.
+type Template struct {
+	// Name, when set, looks up a named built-in template instead of using
+	// Body directly.
+	Name string `yaml:"name,omitempty"`
+	Body string `yaml:"body,omitempty"`
+
+	System    string `yaml:"system,omitempty"`
+	User      string `yaml:"user,omitempty"`
+	Assistant string `yaml:"assistant,omitempty"`
+}
+
+// Config is the per-model YAML document loaded from
+// ~/.ollama/models/<name>.yaml or from the embedded defaults.
+type Config struct {
+	Template Template `yaml:"template"`
+	Stop     []string `yaml:"stop,omitempty"`
+
+	// Backend selects which entry in the backend registry should serve
+	// this model, e.g. "llama", "gpt4all", "bert".
+	Backend string `yaml:"backend,omitempty"`
+
+	ModelOptions   *api.ModelOptions   `yaml:"model_options,omitempty"`
+	PredictOptions *api.PredictOptions `yaml:"predict_options,omitempty"`
+}
+
+// Validate checks that a config is internally consistent enough to be used
+// to serve a request.
+func (c *Config) Validate() error {
+	if c.Template.Name == "" && c.Template.Body == "" {
+		return fmt.Errorf("modelconfig: template.name or template.body must be set")
+	}
+
+	if c.Backend == "" {
+		return fmt.Errorf("modelconfig: backend must be set")
+	}
+
+	return nil
+}
+
+// namedTemplates are built-in prompt templates that a config can reference
+// by name (template.name) instead of inlining the body.
+var namedTemplates = map[string]string{
+	"llama2": "[INST] {{.Prompt}} [/INST]",
+	"gemma":  "<start_of_turn>user\n{{.Prompt}}<end_of_turn>\n<start_of_turn>model\n",
+}
+
+// NamedTemplate returns the body of a built-in template by name.
+func NamedTemplate(name string) (string, error) {
+	body, ok := namedTemplates[name]
+	if !ok {
+		return "", fmt.Errorf("modelconfig: no named template %q", name)
+	}
+	return body, nil
+}
+
+// Resolve loads the config for model, trying the user's
+// ~/.ollama/models/<name>.yaml first and falling back to an embedded
+// default matching the same base name. It returns an error rather than
+// guessing if no config matches either location.
+func Resolve(model string) (*Config, error) {
+	name := strings.TrimSuffix(filepath.Base(model), filepath.Ext(model))
+
+	cfg, err := loadUserConfig(name)
+	if err == nil {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	cfg, err = loadDefaultConfig(name)
+	if err != nil {
+		return nil, fmt.Errorf("modelconfig: no config found for %q: %w", name, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadUserConfig(name string) (*Config, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(home, ".ollama", "models", name+".yaml")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("modelconfig: parsing %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+func loadDefaultConfig(name string) (*Config, error) {
+	b, err := defaultsFS.ReadFile(filepath.Join("defaults", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("no embedded default for %q", name)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// MergeModelOptions overlays any non-zero-value fields set on req on top of
+// the config's defaults, returning a new api.ModelOptions.
+func MergeModelOptions(cfg *Config, req *api.ModelOptions) api.ModelOptions {
+	merged := api.DefaultModelOptions
+	if cfg != nil && cfg.ModelOptions != nil {
+		merged = *cfg.ModelOptions
+	}
+
+	if req == nil {
+		return merged
+	}
+
+	if req.ContextSize != 0 {
+		merged.ContextSize = req.ContextSize
+	}
+	if req.Seed != 0 {
+		merged.Seed = req.Seed
+	}
+	if req.NGPULayers != 0 {
+		merged.NGPULayers = req.NGPULayers
+	}
+	if req.MainGPU != "" {
+		merged.MainGPU = req.MainGPU
+	}
+	if req.TensorSplit != "" {
+		merged.TensorSplit = req.TensorSplit
+	}
+	if req.MMap {
+		merged.MMap = req.MMap
+	}
+	if req.MLock {
+		merged.MLock = req.MLock
+	}
+	if req.Embeddings {
+		merged.Embeddings = req.Embeddings
+	}
+	if req.NUMA {
+		merged.NUMA = req.NUMA
+	}
+	if req.VocabOnly {
+		merged.VocabOnly = req.VocabOnly
+	}
+
+	return merged
+}
+
+// MergePredictOptions overlays any non-zero-value fields set on req on top
+// of the config's defaults, returning a new api.PredictOptions.
+func MergePredictOptions(cfg *Config, req *api.PredictOptions) api.PredictOptions {
+	merged := api.DefaultPredictOptions
+	if cfg != nil && cfg.PredictOptions != nil {
+		merged = *cfg.PredictOptions
+	}
+
+	if req == nil {
+		return merged
+	}
+
+	if req.Seed != 0 {
+		merged.Seed = req.Seed
+	}
+	if req.Tokens != 0 {
+		merged.Tokens = req.Tokens
+	}
+	if req.Threads != 0 {
+		merged.Threads = req.Threads
+	}
+	if req.TopK != 0 {
+		merged.TopK = req.TopK
+	}
+	if req.TopP != 0 {
+		merged.TopP = req.TopP
+	}
+	if req.Temperature != 0 {
+		merged.Temperature = req.Temperature
+	}
+	if req.Penalty != 0 {
+		merged.Penalty = req.Penalty
+	}
+	if req.Repeat != 0 {
+		merged.Repeat = req.Repeat
+	}
+	if req.Batch != 0 {
+		merged.Batch = req.Batch
+	}
+	if req.NKeep != 0 {
+		merged.NKeep = req.NKeep
+	}
+	if req.Mirostat != 0 {
+		merged.Mirostat = req.Mirostat
+	}
+	if req.MirostatTAU != 0 {
+		merged.MirostatTAU = req.MirostatTAU
+	}
+	if req.MirostatETA != 0 {
+		merged.MirostatETA = req.MirostatETA
+	}
+	if req.TailFreeSamplingZ != 0 {
+		merged.TailFreeSamplingZ = req.TailFreeSamplingZ
+	}
+	if req.TypicalP != 0 {
+		merged.TypicalP = req.TypicalP
+	}
+	if req.FrequencyPenalty != 0 {
+		merged.FrequencyPenalty = req.FrequencyPenalty
+	}
+	if req.PresencePenalty != 0 {
+		merged.PresencePenalty = req.PresencePenalty
+	}
+
+	return merged
+}
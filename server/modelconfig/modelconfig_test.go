@@ -0,0 +1,97 @@
+package modelconfig
+
+import (
+	"testing"
+
+	"github.com/jmorganca/ollama/api"
+)
+
+func TestMergeModelOptionsPrecedence(t *testing.T) {
+	cfg := &Config{
+		ModelOptions: &api.ModelOptions{
+			ContextSize: 2048,
+			NGPULayers:  10,
+		},
+	}
+
+	req := &api.ModelOptions{
+		ContextSize: 4096,
+	}
+
+	merged := MergeModelOptions(cfg, req)
+	if merged.ContextSize != 4096 {
+		t.Errorf("expected request ContextSize to win, got %d", merged.ContextSize)
+	}
+	if merged.NGPULayers != 10 {
+		t.Errorf("expected config NGPULayers to survive, got %d", merged.NGPULayers)
+	}
+}
+
+func TestMergeModelOptionsMissingConfigFallsBackToDefaults(t *testing.T) {
+	merged := MergeModelOptions(&Config{}, nil)
+	if merged != api.DefaultModelOptions {
+		t.Errorf("expected defaults when config and request are both empty, got %+v", merged)
+	}
+}
+
+func TestMergePredictOptionsPrecedence(t *testing.T) {
+	cfg := &Config{
+		PredictOptions: &api.PredictOptions{
+			Temperature: 0.2,
+			TopK:        20,
+		},
+	}
+
+	req := &api.PredictOptions{
+		Temperature: 0.9,
+	}
+
+	merged := MergePredictOptions(cfg, req)
+	if merged.Temperature != 0.9 {
+		t.Errorf("expected request Temperature to win, got %f", merged.Temperature)
+	}
+	if merged.TopK != 20 {
+		t.Errorf("expected config TopK to survive, got %d", merged.TopK)
+	}
+}
+
+func TestValidateRequiresTemplateAndBackend(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{"missing both", Config{}, false},
+		{"missing backend", Config{Template: Template{Body: "{{.Prompt}}"}}, false},
+		{"missing template", Config{Backend: "llama"}, false},
+		{"valid", Config{Backend: "llama", Template: Template{Body: "{{.Prompt}}"}}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.ok && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if !tc.ok && err == nil {
+				t.Errorf("expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestResolveFallsBackToEmbeddedDefaults(t *testing.T) {
+	cfg, err := Resolve("/models/llama.gguf")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if cfg.Backend != "llama" {
+		t.Errorf("expected embedded llama default, got backend %q", cfg.Backend)
+	}
+}
+
+func TestResolveErrorsWhenNoConfigMatches(t *testing.T) {
+	if _, err := Resolve("totally-unknown-model"); err == nil {
+		t.Error("expected an error when no config matches, got nil")
+	}
+}
@@ -0,0 +1,106 @@
+package openai
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ollama/ollama/pkg/backend"
+)
+
+// TranscriptionResponse mirrors OpenAI's /v1/audio/transcriptions response.
+type TranscriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// SpeechRequest mirrors OpenAI's /v1/audio/speech request.
+type SpeechRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+func registerAudioRoutes(r *gin.Engine) {
+	r.POST("/v1/audio/transcriptions", audioTranscriptions)
+	r.POST("/v1/audio/speech", audioSpeech)
+}
+
+func audioTranscriptions(c *gin.Context) {
+	model := c.PostForm("model")
+	if model == "" {
+		model = "whisper"
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing multipart field \"file\""})
+		return
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer f.Close()
+
+	audio, err := io.ReadAll(f)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handle, err := modelPool.Acquire(c.Request.Context(), model, backend.ModelOpts{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer handle.Release()
+
+	t, ok := handle.Backend().(backend.Transcriber)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model does not support transcription"})
+		return
+	}
+
+	text, err := t.Transcribe(c.Request.Context(), audio)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TranscriptionResponse{Text: text})
+}
+
+func audioSpeech(c *gin.Context) {
+	var req SpeechRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Model == "" {
+		req.Model = "piper"
+	}
+
+	handle, err := modelPool.Acquire(c.Request.Context(), req.Model, backend.ModelOpts{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer handle.Release()
+
+	s, ok := handle.Backend().(backend.Synthesizer)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model does not support speech synthesis"})
+		return
+	}
+
+	audio, err := s.Synthesize(c.Request.Context(), req.Input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "audio/wav", audio)
+}
@@ -0,0 +1,334 @@
+package openai
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/ollama/ollama/pkg/backend"
+	"github.com/ollama/ollama/server/modelconfig"
+	"github.com/ollama/ollama/server/pool"
+)
+
+// modelPool is set by RegisterRoutes so handlers share the same pooled
+// backends as the native /api/generate endpoint.
+var modelPool *pool.Pool
+
+// RegisterRoutes mounts the OpenAI-compatible endpoints on r, serving
+// requests through p so they share loaded backends with the rest of the
+// server.
+func RegisterRoutes(r *gin.Engine, p *pool.Pool) {
+	modelPool = p
+	r.POST("/v1/chat/completions", chatCompletions)
+	r.POST("/v1/completions", completions)
+	r.POST("/v1/embeddings", embeddings)
+	registerAudioRoutes(r)
+}
+
+func chatCompletions(c *gin.Context) {
+	var req ChatCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg, err := modelconfig.Resolve(req.Model)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handle, err := modelPool.Acquire(c.Request.Context(), req.Model, backend.ModelOpts{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer handle.Release()
+	model := handle.Backend()
+
+	prompt := renderChatPrompt(cfg, req.Messages)
+	opts := samplingOpts(req.Temperature, req.TopP, req.FrequencyPenalty, req.PresencePenalty, req.MaxTokens, req.Stop, cfg)
+	opts.Prompt = prompt
+
+	promptTokens, _ := model.Encode(c.Request.Context(), prompt)
+
+	if req.Stream {
+		streamChatCompletion(c, model, req.Model, opts)
+		return
+	}
+
+	var sb strings.Builder
+	if err := model.Predict(c.Request.Context(), opts, func(r backend.PredictResult) {
+		sb.WriteString(r.Token)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	completionTokens, _ := model.Encode(c.Request.Context(), sb.String())
+	stop := "stop"
+	c.JSON(http.StatusOK, ChatCompletionResponse{
+		ID:     "chatcmpl-ollama",
+		Object: "chat.completion",
+		Model:  req.Model,
+		Choices: []ChatCompletionChoice{{
+			Index:        0,
+			Message:      &ChatMessage{Role: "assistant", Content: sb.String()},
+			FinishReason: &stop,
+		}},
+		Usage: Usage{
+			PromptTokens:     len(promptTokens),
+			CompletionTokens: len(completionTokens),
+			TotalTokens:      len(promptTokens) + len(completionTokens),
+		},
+	})
+}
+
+func streamChatCompletion(c *gin.Context, model backend.Backend, modelName string, opts backend.PredictOpts) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		model.Predict(c.Request.Context(), opts, func(r backend.PredictResult) {
+			ch <- r.Token
+		})
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		token, ok := <-ch
+		if !ok {
+			writeSSE(w, "[DONE]")
+			return false
+		}
+
+		chunk := ChatCompletionChunk{
+			ID:     "chatcmpl-ollama",
+			Object: "chat.completion.chunk",
+			Model:  modelName,
+			Choices: []ChatCompletionChoice{{
+				Index: 0,
+				Delta: ChatMessage{Content: token},
+			}},
+		}
+		writeSSEJSON(w, chunk)
+		return true
+	})
+}
+
+func streamCompletion(c *gin.Context, model backend.Backend, modelName string, opts backend.PredictOpts) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		model.Predict(c.Request.Context(), opts, func(r backend.PredictResult) {
+			ch <- r.Token
+		})
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		token, ok := <-ch
+		if !ok {
+			writeSSE(w, "[DONE]")
+			return false
+		}
+
+		chunk := CompletionChunk{
+			ID:     "cmpl-ollama",
+			Object: "text_completion.chunk",
+			Model:  modelName,
+			Choices: []CompletionChoice{{
+				Index: 0,
+				Text:  token,
+			}},
+		}
+		writeSSEJSON(w, chunk)
+		return true
+	})
+}
+
+func completions(c *gin.Context) {
+	var req CompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg, err := modelconfig.Resolve(req.Model)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handle, err := modelPool.Acquire(c.Request.Context(), req.Model, backend.ModelOpts{})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer handle.Release()
+	model := handle.Backend()
+
+	opts := samplingOpts(req.Temperature, req.TopP, req.FrequencyPenalty, req.PresencePenalty, req.MaxTokens, req.Stop, cfg)
+	opts.Prompt = req.Prompt
+
+	promptTokens, _ := model.Encode(c.Request.Context(), req.Prompt)
+
+	if req.Stream {
+		streamCompletion(c, model, req.Model, opts)
+		return
+	}
+
+	var sb strings.Builder
+	if err := model.Predict(c.Request.Context(), opts, func(r backend.PredictResult) {
+		sb.WriteString(r.Token)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	completionTokens, _ := model.Encode(c.Request.Context(), sb.String())
+	stop := "stop"
+	c.JSON(http.StatusOK, CompletionResponse{
+		ID:     "cmpl-ollama",
+		Object: "text_completion",
+		Model:  req.Model,
+		Choices: []CompletionChoice{{
+			Index:        0,
+			Text:         sb.String(),
+			FinishReason: &stop,
+		}},
+		Usage: Usage{
+			PromptTokens:     len(promptTokens),
+			CompletionTokens: len(completionTokens),
+			TotalTokens:      len(promptTokens) + len(completionTokens),
+		},
+	})
+}
+
+func embeddings(c *gin.Context) {
+	var req EmbeddingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var inputs []string
+	switch v := req.Input.(type) {
+	case string:
+		inputs = []string{v}
+	case []any:
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "input must be a string or array of strings"})
+				return
+			}
+			inputs = append(inputs, s)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "input must be a string or array of strings"})
+		return
+	}
+
+	handle, err := modelPool.Acquire(c.Request.Context(), req.Model, backend.ModelOpts{Embeddings: true})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer handle.Release()
+	model := handle.Backend()
+
+	var data []Embedding
+	var totalTokens int
+	for i, input := range inputs {
+		emb, err := model.Embedding(c.Request.Context(), input)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		data = append(data, Embedding{Object: "embedding", Index: i, Embedding: emb})
+
+		tokens, _ := model.Encode(c.Request.Context(), input)
+		totalTokens += len(tokens)
+	}
+
+	c.JSON(http.StatusOK, EmbeddingResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   data,
+		Usage:  Usage{PromptTokens: totalTokens, TotalTokens: totalTokens},
+	})
+}
+
+// renderChatPrompt concatenates the per-role templates declared in cfg for
+// each message, falling back to a plain "role: content" line when a role
+// has no template of its own.
+func renderChatPrompt(cfg *modelconfig.Config, messages []ChatMessage) string {
+	var sb strings.Builder
+	for _, m := range messages {
+		var tmpl string
+		switch m.Role {
+		case "system":
+			tmpl = cfg.Template.System
+		case "user":
+			tmpl = cfg.Template.User
+		case "assistant":
+			tmpl = cfg.Template.Assistant
+		}
+
+		if tmpl == "" {
+			sb.WriteString(m.Role)
+			sb.WriteString(": ")
+			sb.WriteString(m.Content)
+			sb.WriteString("\n")
+			continue
+		}
+
+		sb.WriteString(strings.ReplaceAll(tmpl, "{{.Content}}", m.Content))
+	}
+	return sb.String()
+}
+
+// samplingOpts maps OpenAI sampling fields onto backend.PredictOpts,
+// falling back to the model config's defaults for anything left unset.
+func samplingOpts(temperature, topP, frequencyPenalty, presencePenalty *float64, maxTokens *int, stop []string, cfg *modelconfig.Config) backend.PredictOpts {
+	var opts backend.PredictOpts
+	if cfg != nil && cfg.PredictOptions != nil {
+		opts.Temperature = cfg.PredictOptions.Temperature
+		opts.TopP = cfg.PredictOptions.TopP
+		opts.FrequencyPenalty = cfg.PredictOptions.FrequencyPenalty
+		opts.PresencePenalty = cfg.PredictOptions.PresencePenalty
+		opts.Tokens = cfg.PredictOptions.Tokens
+	}
+
+	if temperature != nil {
+		opts.Temperature = *temperature
+	}
+	if topP != nil {
+		opts.TopP = *topP
+	}
+	if frequencyPenalty != nil {
+		opts.FrequencyPenalty = *frequencyPenalty
+	}
+	if presencePenalty != nil {
+		opts.PresencePenalty = *presencePenalty
+	}
+	if maxTokens != nil {
+		opts.Tokens = *maxTokens
+	}
+
+	if len(stop) > 0 {
+		opts.Stop = stop
+	} else if cfg != nil {
+		opts.Stop = cfg.Stop
+	}
+
+	return opts
+}
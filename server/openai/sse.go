@@ -0,0 +1,21 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// writeSSE writes a single raw "data: <payload>\n\n" server-sent event.
+func writeSSE(w io.Writer, payload string) {
+	fmt.Fprintf(w, "data: %s\n\n", payload)
+}
+
+// writeSSEJSON marshals v and writes it as an SSE event.
+func writeSSEJSON(w io.Writer, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	writeSSE(w, string(b))
+}
@@ -0,0 +1,116 @@
+// Package openai exposes an OpenAI-compatible HTTP surface under /v1/ so
+// existing OpenAI SDKs can point their base_url at an ollama server without
+// code changes. Requests are translated onto the same model-config and
+// backend machinery used by the native /api/generate endpoint.
+package openai
+
+// ChatMessage is a single role/content pair, matching the OpenAI chat
+// completions message shape.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionRequest mirrors the subset of the OpenAI
+// /v1/chat/completions request body that ollama can act on.
+type ChatCompletionRequest struct {
+	Model            string        `json:"model"`
+	Messages         []ChatMessage `json:"messages"`
+	Temperature      *float64      `json:"temperature,omitempty"`
+	TopP             *float64      `json:"top_p,omitempty"`
+	FrequencyPenalty *float64      `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64      `json:"presence_penalty,omitempty"`
+	Stop             []string      `json:"stop,omitempty"`
+	MaxTokens        *int          `json:"max_tokens,omitempty"`
+	N                *int          `json:"n,omitempty"`
+	Stream           bool          `json:"stream,omitempty"`
+}
+
+// ChatCompletionChunk is a single SSE event emitted while streaming a chat
+// completion.
+type ChatCompletionChunk struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+}
+
+type ChatCompletionChoice struct {
+	Index        int          `json:"index"`
+	Delta        ChatMessage  `json:"delta,omitempty"`
+	Message      *ChatMessage `json:"message,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+// ChatCompletionResponse is the non-streaming /v1/chat/completions response.
+type ChatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   Usage                  `json:"usage"`
+}
+
+// CompletionRequest mirrors /v1/completions.
+type CompletionRequest struct {
+	Model            string   `json:"model"`
+	Prompt           string   `json:"prompt"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	MaxTokens        *int     `json:"max_tokens,omitempty"`
+	N                *int     `json:"n,omitempty"`
+	Stream           bool     `json:"stream,omitempty"`
+}
+
+type CompletionChoice struct {
+	Index        int     `json:"index"`
+	Text         string  `json:"text"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+type CompletionResponse struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Model   string             `json:"model"`
+	Choices []CompletionChoice `json:"choices"`
+	Usage   Usage              `json:"usage"`
+}
+
+// CompletionChunk is a single SSE event emitted while streaming
+// /v1/completions.
+type CompletionChunk struct {
+	ID      string             `json:"id"`
+	Object  string             `json:"object"`
+	Model   string             `json:"model"`
+	Choices []CompletionChoice `json:"choices"`
+}
+
+// Usage reports token counts the way OpenAI clients expect them.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// EmbeddingRequest mirrors /v1/embeddings. Input accepts either a single
+// string or a list of strings, so it's parsed manually in the handler.
+type EmbeddingRequest struct {
+	Model string `json:"model"`
+	Input any    `json:"input"`
+}
+
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
+type EmbeddingResponse struct {
+	Object string      `json:"object"`
+	Model  string      `json:"model"`
+	Data   []Embedding `json:"data"`
+	Usage  Usage       `json:"usage"`
+}
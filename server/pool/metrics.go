@@ -0,0 +1,116 @@
+package pool
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	v atomic.Uint64
+}
+
+func (c *Counter) Add(n uint64)  { c.v.Add(n) }
+func (c *Counter) Value() uint64 { return c.v.Load() }
+
+// Gauge is a value that can go up or down.
+type Gauge struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.v = v
+}
+
+// Add adjusts the gauge by delta, which may be negative.
+func (g *Gauge) Add(delta float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.v += delta
+}
+
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.v
+}
+
+// Histogram tracks a running count/sum, enough to compute an average without
+// pulling in a full metrics client library.
+type Histogram struct {
+	mu    sync.Mutex
+	count uint64
+	sum   float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	h.sum += v
+}
+
+func (h *Histogram) Snapshot() (count uint64, sum float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count, h.sum
+}
+
+// Metrics are the pool's Prometheus gauges/counters, exposed at /metrics.
+type Metrics struct {
+	LoadedModels Gauge
+	QueueDepth   Gauge
+	LoadCount    Counter
+	EvictCount   Counter
+	CacheHits    Counter
+	LoadSeconds  Histogram
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{}
+}
+
+// WriteTo renders the pool's metrics in the Prometheus text exposition
+// format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var n int
+	write := func(format string, args ...any) {
+		c, _ := fmt.Fprintf(w, format, args...)
+		n += c
+	}
+
+	write("# HELP ollama_pool_loaded_models Number of models currently loaded in the pool.\n")
+	write("# TYPE ollama_pool_loaded_models gauge\n")
+	write("ollama_pool_loaded_models %g\n", m.LoadedModels.Value())
+
+	write("# HELP ollama_pool_queue_depth Number of requests waiting for a model slot.\n")
+	write("# TYPE ollama_pool_queue_depth gauge\n")
+	write("ollama_pool_queue_depth %g\n", m.QueueDepth.Value())
+
+	write("# HELP ollama_pool_load_total Number of times a model was loaded into the pool.\n")
+	write("# TYPE ollama_pool_load_total counter\n")
+	write("ollama_pool_load_total %d\n", m.LoadCount.Value())
+
+	write("# HELP ollama_pool_evict_total Number of times a model was evicted from the pool.\n")
+	write("# TYPE ollama_pool_evict_total counter\n")
+	write("ollama_pool_evict_total %d\n", m.EvictCount.Value())
+
+	write("# HELP ollama_pool_cache_hits_total Number of Acquire calls served without a reload.\n")
+	write("# TYPE ollama_pool_cache_hits_total counter\n")
+	write("ollama_pool_cache_hits_total %d\n", m.CacheHits.Value())
+
+	count, sum := m.LoadSeconds.Snapshot()
+	write("# HELP ollama_pool_load_seconds_sum Sum of time spent loading a model into the pool, in seconds.\n")
+	write("# TYPE ollama_pool_load_seconds_sum counter\n")
+	write("ollama_pool_load_seconds_sum %g\n", sum)
+	write("# HELP ollama_pool_load_seconds_count Number of model loads observed.\n")
+	write("# TYPE ollama_pool_load_seconds_count counter\n")
+	write("ollama_pool_load_seconds_count %d\n", count)
+
+	return int64(n), nil
+}
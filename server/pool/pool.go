@@ -0,0 +1,357 @@
+// Package pool keeps loaded backend.Backend instances alive across requests
+// in an LRU cache, so the server can serve several models concurrently
+// instead of reloading a model from disk on every request.
+package pool
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ollama/ollama/pkg/backend"
+)
+
+// Key identifies a loaded backend by the model path and the options it was
+// loaded with, since the same model loaded with different options (e.g.
+// NGPULayers) can't share a backend instance.
+type Key struct {
+	Model string
+	Opts  backend.ModelOpts
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s:%+v", k.Model, k.Opts)
+}
+
+// Config controls how the pool manages capacity.
+type Config struct {
+	// MaxModels is the maximum number of distinct (model, opts) pairs kept
+	// loaded at once. 0 means unlimited.
+	MaxModels int
+	// MaxVRAMBytes bounds the sum of each loaded model's VRAM hint, which
+	// is derived from NGPULayers alone; TensorSplit is not taken into
+	// account. 0 means unbounded.
+	MaxVRAMBytes uint64
+	// IdleTTL is how long an unused model stays loaded before it's evicted.
+	IdleTTL time.Duration
+}
+
+type entry struct {
+	key        Key
+	backend    backend.Backend
+	vramBytes  uint64
+	refCount   int
+	lastUsed   time.Time
+	elem       *list.Element // position in the LRU list
+	loadedOnce bool
+}
+
+// Pool is an LRU cache of loaded backends, keyed by model+options, with a
+// fair-scheduling queue for callers that arrive once the pool is full and
+// idle eviction.
+type Pool struct {
+	cfg Config
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	lru     *list.List // front = most recently used
+
+	// waiters holds one roomWaiter per Acquire call currently queued in
+	// makeRoom, in arrival order (front = longest waiting). Each time a
+	// slot might have freed up, wakeNextLocked signals only the front
+	// waiter instead of letting all of them re-contend at once. Service is
+	// not strictly FIFO: a caller arriving while others are queued checks
+	// for room before queuing, and a woken waiter that still finds no room
+	// queues again at the back.
+	waiters *list.List
+
+	loaderFor func(ctx context.Context, model string, opts backend.ModelOpts) (backend.Backend, error)
+
+	Metrics *Metrics
+
+	closeCh chan struct{}
+}
+
+// roomWaiter is one Acquire call's place in the queue; ready is closed
+// exactly once, by wakeNextLocked/wakeAllLocked, to tell it to recheck
+// capacity.
+type roomWaiter struct {
+	ready chan struct{}
+}
+
+// New creates a Pool. loader is how the pool obtains a backend, already
+// loaded with the model, for a (model, opts) pair that isn't already
+// cached; the pool does not call LoadModel itself. In production this is
+// the server package's resolveAndLoad.
+func New(cfg Config, loader func(ctx context.Context, model string, opts backend.ModelOpts) (backend.Backend, error)) *Pool {
+	p := &Pool{
+		cfg:       cfg,
+		entries:   make(map[string]*entry),
+		lru:       list.New(),
+		waiters:   list.New(),
+		loaderFor: loader,
+		Metrics:   newMetrics(),
+		closeCh:   make(chan struct{}),
+	}
+
+	if cfg.IdleTTL > 0 {
+		go p.evictLoop()
+	}
+
+	return p
+}
+
+// enqueueWaiterLocked adds a new waiter to the back of the queue (the most
+// recent arrival) and returns it along with its list.Element, so the caller
+// can remove itself again if it gives up before being woken. Callers must
+// hold p.mu.
+func (p *Pool) enqueueWaiterLocked() (*roomWaiter, *list.Element) {
+	w := &roomWaiter{ready: make(chan struct{})}
+	return w, p.waiters.PushBack(w)
+}
+
+// wakeNextLocked signals the longest-waiting queued caller, if any, that a
+// slot might have freed up. Only one waiter is woken per call, since each
+// call corresponds to one unit of newly available capacity (one eviction or
+// one entry's refcount reaching zero). Callers must hold p.mu.
+func (p *Pool) wakeNextLocked() {
+	el := p.waiters.Front()
+	if el == nil {
+		return
+	}
+	p.waiters.Remove(el)
+	close(el.Value.(*roomWaiter).ready)
+}
+
+// wakeAllLocked signals every queued caller at once. It's only used by
+// Close: once the pool is torn down, overCapacityLocked is always false (no
+// entries remain), so there's nothing left to serve in order. Callers must
+// hold p.mu.
+func (p *Pool) wakeAllLocked() {
+	for el := p.waiters.Front(); el != nil; el = p.waiters.Front() {
+		p.waiters.Remove(el)
+		close(el.Value.(*roomWaiter).ready)
+	}
+}
+
+// Handle is a leased reference to a pooled backend. Callers must call
+// Release when they're done making requests against it.
+type Handle struct {
+	pool  *Pool
+	entry *entry
+}
+
+func (h *Handle) Backend() backend.Backend {
+	return h.entry.backend
+}
+
+// Release returns the backend to the pool, making it eligible for eviction
+// again once its refcount drops to zero.
+func (h *Handle) Release() {
+	h.pool.mu.Lock()
+	defer h.pool.mu.Unlock()
+
+	h.entry.refCount--
+	h.entry.lastUsed = time.Now()
+	if h.entry.refCount == 0 {
+		h.pool.wakeNextLocked()
+	}
+}
+
+// Acquire returns a Handle to a loaded backend for (model, opts), loading it
+// if it's not already cached. If the pool is at MaxModels capacity, the
+// least-recently-used idle model is evicted first.
+func (p *Pool) Acquire(ctx context.Context, model string, opts backend.ModelOpts) (*Handle, error) {
+	key := Key{Model: model, Opts: opts}
+	keyStr := key.String()
+
+	p.mu.Lock()
+	if e, ok := p.entries[keyStr]; ok {
+		p.lru.MoveToFront(e.elem)
+		e.refCount++
+		e.lastUsed = time.Now()
+		p.mu.Unlock()
+		p.Metrics.CacheHits.Add(1)
+		return &Handle{pool: p, entry: e}, nil
+	}
+	p.mu.Unlock()
+
+	if err := p.makeRoom(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	b, err := p.loaderFor(ctx, model, opts)
+	if err != nil {
+		return nil, err
+	}
+	p.Metrics.LoadCount.Add(1)
+	p.Metrics.LoadSeconds.Observe(time.Since(start).Seconds())
+
+	e := &entry{
+		key:       key,
+		backend:   b,
+		vramBytes: vramEstimate(opts),
+		refCount:  1,
+		lastUsed:  time.Now(),
+	}
+
+	p.mu.Lock()
+	// another goroutine may have raced us to load the same key
+	if existing, ok := p.entries[keyStr]; ok {
+		p.lru.MoveToFront(existing.elem)
+		existing.refCount++
+		existing.lastUsed = time.Now()
+		p.mu.Unlock()
+		b.Close()
+		return &Handle{pool: p, entry: existing}, nil
+	}
+
+	e.elem = p.lru.PushFront(e)
+	p.entries[keyStr] = e
+	p.Metrics.LoadedModels.Set(float64(len(p.entries)))
+	p.mu.Unlock()
+
+	return &Handle{pool: p, entry: e}, nil
+}
+
+// makeRoom checks whether the pool has room for one more model under
+// MaxModels/MaxVRAMBytes. If not, it evicts the least recently used idle
+// entry and returns; it evicts at most one entry per call. If every loaded
+// model is currently in use, it queues the caller and waits for one to
+// free up, rather than failing outright. The order in which queued callers
+// get a slot is not strictly their arrival order (see Pool.waiters).
+func (p *Pool) makeRoom(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		if !p.overCapacityLocked() {
+			p.mu.Unlock()
+			return nil
+		}
+
+		victim := p.oldestIdleLocked()
+		if victim != nil {
+			p.evictLocked(victim)
+			p.mu.Unlock()
+			return nil
+		}
+
+		// everything in the pool is in use; queue and wait for a slot.
+		w, el := p.enqueueWaiterLocked()
+		p.Metrics.QueueDepth.Add(1)
+		p.mu.Unlock()
+
+		select {
+		case <-w.ready:
+			p.Metrics.QueueDepth.Add(-1)
+		case <-ctx.Done():
+			p.mu.Lock()
+			select {
+			case <-w.ready:
+				// we were already woken for our turn but are bailing on
+				// it; pass the slot along instead of dropping it.
+				p.wakeNextLocked()
+			default:
+				p.waiters.Remove(el)
+			}
+			p.mu.Unlock()
+			p.Metrics.QueueDepth.Add(-1)
+			return ctx.Err()
+		}
+	}
+}
+
+func (p *Pool) overCapacityLocked() bool {
+	if p.cfg.MaxModels > 0 && len(p.entries) >= p.cfg.MaxModels {
+		return true
+	}
+	if p.cfg.MaxVRAMBytes > 0 && p.totalVRAMLocked() >= p.cfg.MaxVRAMBytes {
+		return true
+	}
+	return false
+}
+
+func (p *Pool) totalVRAMLocked() uint64 {
+	var total uint64
+	for _, e := range p.entries {
+		total += e.vramBytes
+	}
+	return total
+}
+
+func (p *Pool) oldestIdleLocked() *entry {
+	for el := p.lru.Back(); el != nil; el = el.Prev() {
+		e := el.Value.(*entry)
+		if e.refCount == 0 {
+			return e
+		}
+	}
+	return nil
+}
+
+func (p *Pool) evictLocked(e *entry) {
+	p.lru.Remove(e.elem)
+	delete(p.entries, e.key.String())
+	p.Metrics.EvictCount.Add(1)
+	p.Metrics.LoadedModels.Set(float64(len(p.entries)))
+	e.backend.Close()
+	p.wakeNextLocked()
+}
+
+// evictLoop periodically closes backends that have been idle longer than
+// IdleTTL.
+func (p *Pool) evictLoop() {
+	ticker := time.NewTicker(p.cfg.IdleTTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.closeCh:
+			return
+		case <-ticker.C:
+			p.evictExpired()
+		}
+	}
+}
+
+func (p *Pool) evictExpired() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var expired []*entry
+	for el := p.lru.Back(); el != nil; el = el.Prev() {
+		e := el.Value.(*entry)
+		if e.refCount == 0 && now.Sub(e.lastUsed) >= p.cfg.IdleTTL {
+			expired = append(expired, e)
+		}
+	}
+
+	for _, e := range expired {
+		p.evictLocked(e)
+	}
+}
+
+// Close stops the eviction loop and closes every cached backend.
+func (p *Pool) Close() {
+	close(p.closeCh)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.entries {
+		e.backend.Close()
+	}
+	p.entries = make(map[string]*entry)
+	p.lru = list.New()
+	p.wakeAllLocked()
+}
+
+// vramEstimate derives a rough VRAM budget from the GPU-layer hints on opts.
+// It's intentionally simple: callers that need precision can set
+// MaxVRAMBytes to 0 and manage capacity via MaxModels instead.
+func vramEstimate(opts backend.ModelOpts) uint64 {
+	const bytesPerLayer = 200 << 20 // ~200MB/layer, a coarse 7B-class estimate
+	return uint64(opts.NGPULayers) * bytesPerLayer
+}
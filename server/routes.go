@@ -1,12 +1,11 @@
 package server
 
 import (
-	"embed"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"log"
-	"math"
 	"net"
 	"net/http"
 	"os"
@@ -14,17 +13,25 @@ import (
 	"runtime"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lithammer/fuzzysearch/fuzzy"
 
 	"github.com/jmorganca/ollama/api"
-	"github.com/jmorganca/ollama/llama"
+	_ "github.com/jmorganca/ollama/llm" // registers the llama backend
+	"github.com/ollama/ollama/pkg/backend"
+	_ "github.com/ollama/ollama/pkg/backend/worker" // registers the whisper/piper CLI backends
+	"github.com/ollama/ollama/server/modelconfig"
+	"github.com/ollama/ollama/server/openai"
+	"github.com/ollama/ollama/server/pool"
 )
 
-//go:embed templates/*
-var templatesFS embed.FS
-var templates = template.Must(template.ParseFS(templatesFS, "templates/*.prompt"))
+// modelPool keeps recently used backends loaded so concurrent requests
+// against the same (or different) models don't each pay a full reload.
+var modelPool = pool.New(pool.Config{
+	MaxModels: 4,
+	IdleTTL:   5 * time.Minute,
+}, resolveAndLoad)
 
 func cacheDir() string {
 	home, err := os.UserHomeDir()
@@ -57,46 +64,49 @@ func generate(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		req.Model = path.Join(cacheDir(), "models", req.Model+".bin")
+		req.Model = path.Join(cacheDir(), "models", req.Model+".gguf")
 	}
 
-	modelOpts := getModelOpts(req)
+	cfg, err := modelconfig.Resolve(req.Model)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	modelOpts := mergedModelOpts(cfg, req.ModelOptions)
 	modelOpts.NGPULayers = 1 // hard-code this for now
 
-	model, err := llama.New(req.Model, modelOpts)
+	handle, err := modelPool.Acquire(c.Request.Context(), req.Model, modelOpts)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer model.Free()
+	defer handle.Release()
+	model := handle.Backend()
 
-	templateNames := make([]string, 0, len(templates.Templates()))
-	for _, template := range templates.Templates() {
-		templateNames = append(templateNames, template.Name())
+	tmpl, err := promptTemplate(cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	match, _ := matchRankOne(path.Base(req.Model), templateNames)
-	if template := templates.Lookup(match); template != nil {
-		var sb strings.Builder
-		if err := template.Execute(&sb, req); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-
-		req.Prompt = sb.String()
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
+	req.Prompt = sb.String()
 
-	ch := make(chan string)
-	model.SetTokenCallback(func(token string) bool {
-		ch <- token
-		return true
-	})
-
-	predictOpts := getPredictOpts(req)
+	predictOpts := mergedPredictOpts(cfg, req.PredictOptions)
+	predictOpts.Prompt = req.Prompt
+	predictOpts.Stop = cfg.Stop
 
+	ch := make(chan string)
 	go func() {
 		defer close(ch)
-		_, err := model.Predict(req.Prompt, predictOpts)
+		err := model.Predict(c.Request.Context(), predictOpts, func(r backend.PredictResult) {
+			ch <- r.Token
+		})
 		if err != nil {
 			panic(err)
 		}
@@ -175,6 +185,16 @@ func Serve(ln net.Listener) error {
 	})
 
 	r.POST("/api/generate", generate)
+	r.POST("/api/transcribe", transcribe)
+	r.POST("/api/tts", tts)
+	r.POST("/api/gallery/apply", galleryApply)
+	r.GET("/api/gallery/list", galleryList)
+	r.GET("/api/models", listModels)
+	openai.RegisterRoutes(r, modelPool)
+
+	r.GET("/metrics", func(c *gin.Context) {
+		modelPool.Metrics.WriteTo(c.Writer)
+	})
 
 	log.Printf("Listening on %s", ln.Addr())
 	s := &http.Server{
@@ -184,64 +204,103 @@ func Serve(ln net.Listener) error {
 	return s.Serve(ln)
 }
 
-func matchRankOne(source string, targets []string) (bestMatch string, bestRank int) {
-	bestRank = math.MaxInt
-	for _, target := range targets {
-		if rank := fuzzy.LevenshteinDistance(source, target); bestRank > rank {
-			bestRank = rank
-			bestMatch = target
+// promptTemplate builds the text/template for cfg, either from an inline
+// body or, if set, a named built-in template.
+func promptTemplate(cfg *modelconfig.Config) (*template.Template, error) {
+	body := cfg.Template.Body
+	if cfg.Template.Name != "" {
+		named, err := modelconfig.NamedTemplate(cfg.Template.Name)
+		if err != nil {
+			return nil, err
 		}
+		body = named
+	}
+
+	return template.New("prompt").Parse(body)
+}
+
+// resolveAndLoad resolves model to its config and loads it on the backend
+// the config declares, falling back to autoload when the config doesn't pin
+// one down. It's the Pool's loader, so every Acquire (whether from generate
+// or the OpenAI-compatible handlers) shares the same dispatch logic.
+func resolveAndLoad(ctx context.Context, model string, opts backend.ModelOpts) (backend.Backend, error) {
+	// model names that are themselves a registered backend name (e.g. the
+	// "whisper"/"piper" defaults transcribe/tts fall back to) select that
+	// backend directly, bypassing the GGUF model-config dispatch below.
+	if b, err := backend.Get(model); err == nil {
+		if err := b.LoadModel(ctx, model, opts); err != nil {
+			b.Close()
+			return nil, err
+		}
+		return b, nil
+	}
+
+	cfg, err := modelconfig.Resolve(model)
+	if err != nil {
+		return backend.Autoload(ctx, model, opts)
 	}
 
-	return
+	if cfg.Backend == "" {
+		return backend.Autoload(ctx, model, opts)
+	}
+
+	b, err := backend.Get(cfg.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.LoadModel(ctx, model, opts); err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	return b, nil
 }
 
-func getModelOpts(req api.GenerateRequest) llama.ModelOptions {
-	var opts llama.ModelOptions
-	opts.ContextSize = req.ModelOptions.ContextSize
-	opts.Seed = req.ModelOptions.Seed
-	opts.F16Memory = req.ModelOptions.F16Memory
-	opts.MLock = req.ModelOptions.MLock
-	opts.Embeddings = req.ModelOptions.Embeddings
-	opts.MMap = req.ModelOptions.MMap
-	opts.LowVRAM = req.ModelOptions.LowVRAM
-
-	opts.NBatch = req.ModelOptions.NBatch
-	opts.VocabOnly = req.ModelOptions.VocabOnly
-	opts.NUMA = req.ModelOptions.NUMA
-	opts.NGPULayers = req.ModelOptions.NGPULayers
-	opts.MainGPU = req.ModelOptions.MainGPU
-	opts.TensorSplit = req.ModelOptions.TensorSplit
+func mergedModelOpts(cfg *modelconfig.Config, req *api.ModelOptions) backend.ModelOpts {
+	merged := modelconfig.MergeModelOptions(cfg, req)
+
+	var opts backend.ModelOpts
+	opts.ContextSize = merged.ContextSize
+	opts.Seed = merged.Seed
+	opts.MLock = merged.MLock
+	opts.Embeddings = merged.Embeddings
+	opts.MMap = merged.MMap
+	opts.VocabOnly = merged.VocabOnly
+	opts.NUMA = merged.NUMA
+	opts.NGPULayers = merged.NGPULayers
+	opts.MainGPU = merged.MainGPU
+	opts.TensorSplit = merged.TensorSplit
 
 	return opts
 }
 
-func getPredictOpts(req api.GenerateRequest) llama.PredictOptions {
-	var opts llama.PredictOptions
+func mergedPredictOpts(cfg *modelconfig.Config, req *api.PredictOptions) backend.PredictOpts {
+	merged := modelconfig.MergePredictOptions(cfg, req)
 
-	if req.PredictOptions.Threads == -1 {
+	var opts backend.PredictOpts
+	if merged.Threads == -1 {
 		opts.Threads = runtime.NumCPU()
 	} else {
-		opts.Threads = req.PredictOptions.Threads
-	}
-
-	opts.Seed = req.PredictOptions.Seed
-	opts.Tokens = req.PredictOptions.Tokens
-	opts.Penalty = req.PredictOptions.Penalty
-	opts.Repeat = req.PredictOptions.Repeat
-	opts.Batch = req.PredictOptions.Batch
-	opts.NKeep = req.PredictOptions.NKeep
-	opts.TopK = req.PredictOptions.TopK
-	opts.TopP = req.PredictOptions.TopP
-	opts.TailFreeSamplingZ = req.PredictOptions.TailFreeSamplingZ
-	opts.TypicalP = req.PredictOptions.TypicalP
-	opts.Temperature = req.PredictOptions.Temperature
-	opts.FrequencyPenalty = req.PredictOptions.FrequencyPenalty
-	opts.PresencePenalty = req.PredictOptions.PresencePenalty
-	opts.Mirostat = req.PredictOptions.Mirostat
-	opts.MirostatTAU = req.PredictOptions.MirostatTAU
-	opts.MirostatETA = req.PredictOptions.MirostatETA
-	opts.MMap = req.PredictOptions.MMap
+		opts.Threads = merged.Threads
+	}
+
+	opts.Seed = merged.Seed
+	opts.Tokens = merged.Tokens
+	opts.Penalty = merged.Penalty
+	opts.Repeat = merged.Repeat
+	opts.Batch = merged.Batch
+	opts.NKeep = merged.NKeep
+	opts.TopK = merged.TopK
+	opts.TopP = merged.TopP
+	opts.TailFreeSamplingZ = merged.TailFreeSamplingZ
+	opts.TypicalP = merged.TypicalP
+	opts.Temperature = merged.Temperature
+	opts.FrequencyPenalty = merged.FrequencyPenalty
+	opts.PresencePenalty = merged.PresencePenalty
+	opts.Mirostat = merged.Mirostat
+	opts.MirostatTAU = merged.MirostatTAU
+	opts.MirostatETA = merged.MirostatETA
 
 	return opts
 }